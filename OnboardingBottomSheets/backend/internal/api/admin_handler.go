@@ -0,0 +1,414 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"onboarding-backend/internal/models"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles support and admin endpoints
+type AdminHandler struct {
+	feedbackService   *services.FeedbackService
+	analyticsService  *services.AnalyticsService
+	attachmentService *services.AttachmentService
+	slackService      services.SlackService
+	ticketService     *services.TicketService
+	userService       *services.UserService
+	authService       *services.AuthService
+	redactor          *services.Redactor
+	background        *services.Background
+	logger            *slog.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(
+	feedbackService *services.FeedbackService,
+	analyticsService *services.AnalyticsService,
+	attachmentService *services.AttachmentService,
+	slackService services.SlackService,
+	ticketService *services.TicketService,
+	userService *services.UserService,
+	authService *services.AuthService,
+	redactor *services.Redactor,
+	background *services.Background,
+	logger *slog.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		feedbackService:   feedbackService,
+		analyticsService:  analyticsService,
+		attachmentService: attachmentService,
+		slackService:      slackService,
+		ticketService:     ticketService,
+		userService:       userService,
+		authService:       authService,
+		redactor:          redactor,
+		background:        background,
+		logger:            logger,
+	}
+}
+
+// redactFor removes personal data from feedback shown to staff other than admins, as
+// exports do
+func (h *AdminHandler) redactFor(c *gin.Context, feedback *models.Feedback) *models.Feedback {
+	if c.GetString("role") == models.RoleAdmin {
+		return feedback
+	}
+	return h.redactor.RedactFeedback(feedback)
+}
+
+// ListAllFeedback returns a page of feedback from every user, newest first.
+// Accepts the same parameters as the user listing plus an optional user_id.
+func (h *AdminHandler) ListAllFeedback(c *gin.Context) {
+	query, err := parseFeedbackQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.UserID = c.Query("user_id")
+
+	page, err := h.feedbackService.ListFeedback(query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	for i, feedback := range page.Feedback {
+		page.Feedback[i] = h.redactFor(c, feedback)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feedback":    page.Feedback,
+		"count":       len(page.Feedback),
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// SearchFeedback runs a full-text search over feedback (?q=, optional limit)
+func (h *AdminHandler) SearchFeedback(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.feedbackService.SearchFeedback(c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query must contain at least one searchable word"})
+		return
+	}
+	for _, result := range results {
+		result.Feedback = h.redactFor(c, result.Feedback)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+// ExportFeedback streams feedback matching the listing filters as CSV or JSON Lines.
+// Personal data is redacted unless an admin asks for redact=false.
+func (h *AdminHandler) ExportFeedback(c *gin.Context) {
+	query, err := parseFeedbackQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.UserID = c.Query("user_id")
+
+	redact, err := strconv.ParseBool(c.DefaultQuery("redact", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "redact must be true or false"})
+		return
+	}
+	if !redact && c.GetString("role") != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can export unredacted feedback"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	var writer services.FeedbackExportWriter
+	switch format {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		writer = services.NewCSVFeedbackWriter(c.Writer)
+	case "jsonl", "ndjson":
+		c.Header("Content-Type", "application/x-ndjson")
+		writer = services.NewJSONLFeedbackWriter(c.Writer)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be csv, jsonl or ndjson"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename(query.From, query.To, format)))
+	c.Status(http.StatusOK)
+
+	// A large export can outlast the server's write timeout, so lift it for this response
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	// Flush periodically so the download starts before the export finishes
+	written := 0
+	err = h.feedbackService.ExportFeedback(query, func(fb *models.Feedback) error {
+		if redact {
+			fb = h.redactor.RedactFeedback(fb)
+		}
+		if err := writer.Write(fb); err != nil {
+			return err
+		}
+		if written++; written%services.MaxFeedbackPageSize == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		// Headers are already sent, so the error can only be recorded
+		c.Error(err)
+	}
+}
+
+// exportFilename names an export after its date range, e.g. feedback-2024-01-01-to-2024-01-31.csv
+func exportFilename(from, to time.Time, ext string) string {
+	start := "start"
+	if !from.IsZero() {
+		start = from.UTC().Format("2006-01-02")
+	}
+	end := time.Now().UTC().Format("2006-01-02")
+	if !to.IsZero() {
+		// to is exclusive, so name the last day included
+		end = to.Add(-time.Nanosecond).UTC().Format("2006-01-02")
+	}
+	return fmt.Sprintf("feedback-%s-to-%s.%s", start, end, ext)
+}
+
+// FeedbackAnalytics returns aggregates over a window given either as days (ending
+// today, default 30) or as a from/to date range
+func (h *AdminHandler) FeedbackAnalytics(c *gin.Context) {
+	from, err := parseDateParam(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD) or RFC 3339 time"})
+		return
+	}
+	to, err := parseDateParam(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD) or RFC 3339 time"})
+		return
+	}
+
+	// Windows end at a day boundary by default so repeated requests share a cache entry
+	if to.IsZero() {
+		to = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if from.IsZero() {
+		days := 30
+		if value := c.Query("days"); value != "" {
+			if days, err = strconv.Atoi(value); err != nil || days < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive number"})
+				return
+			}
+		}
+		from = to.AddDate(0, 0, -days)
+	}
+
+	analytics, err := h.analyticsService.FeedbackAnalytics(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The window must end after it starts and be at most 366 days long"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetFeedback returns a feedback entry with its status history and replies
+func (h *AdminHandler) GetFeedback(c *gin.Context) {
+	feedback, exists := h.feedbackService.GetFeedback(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feedback not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.redactFor(c, feedback))
+}
+
+// UpdateFeedbackStatus moves feedback through its lifecycle
+func (h *AdminHandler) UpdateFeedbackStatus(c *gin.Context) {
+	var req models.UpdateFeedbackStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be new, triaged, in_progress, resolved or wont_fix"})
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+
+	feedback, err := h.feedbackService.UpdateStatus(c.Param("id"), adminID.(string), req.Status, req.Note)
+	if err != nil {
+		respondWithFeedbackError(c, err, "Failed to update status")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.redactFor(c, feedback))
+}
+
+// ModerateFeedback approves or rejects feedback held by moderation. Feedback approved for
+// the first time is published to Slack with its attachments, and a ticket is filed if it
+// matches a rule. Feedback held after an edit was published already.
+func (h *AdminHandler) ModerateFeedback(c *gin.Context) {
+	var req models.ModerateFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action must be approve or reject"})
+		return
+	}
+
+	feedback, published, err := h.feedbackService.ReviewHeldFeedback(c.Param("id"), req.Action == "approve")
+	if err != nil {
+		respondWithFeedbackError(c, err, "Failed to moderate feedback")
+		return
+	}
+
+	if published {
+		ctx := context.WithoutCancel(c.Request.Context())
+		h.background.Go(func() {
+			if err := h.slackService.PublishFeedback(ctx, feedback); err != nil {
+				h.logger.ErrorContext(ctx, "failed to publish feedback to slack", "feedback_id", feedback.ID, "error", err)
+				return
+			}
+			for _, attachment := range feedback.Attachments {
+				if err := h.slackService.PublishAttachment(ctx, feedback, attachment, h.attachmentService.SlackURL(attachment)); err != nil {
+					h.logger.ErrorContext(ctx, "failed to publish attachment to slack",
+						"feedback_id", feedback.ID, "attachment_id", attachment.ID, "error", err)
+				}
+			}
+		})
+		h.background.Go(func() { h.ticketService.FileIfMatched(ctx, feedback) })
+	}
+
+	c.JSON(http.StatusOK, h.redactFor(c, feedback))
+}
+
+// CreateTicket files a tracker issue for feedback, whether or not it matches a rule
+func (h *AdminHandler) CreateTicket(c *gin.Context) {
+	feedback, err := h.ticketService.CreateTicket(c.Param("id"))
+	if err != nil {
+		switch err {
+		case services.ErrFeedbackNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Feedback not found"})
+		case services.ErrTicketingDisabled:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Ticketing is not configured"})
+		case services.ErrTicketExists:
+			c.JSON(http.StatusConflict, gin.H{"error": "Feedback already has a ticket"})
+		case services.ErrTicketInProgress:
+			c.JSON(http.StatusConflict, gin.H{"error": "A ticket is already being created"})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to create ticket"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.redactFor(c, feedback))
+}
+
+// ReplyToFeedback adds a staff reply to a feedback thread and emails the author
+func (h *AdminHandler) ReplyToFeedback(c *gin.Context) {
+	adminID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	addReply(c, h.feedbackService, adminID.(string), role.(string))
+}
+
+// LookupUser finds a user by email (?email=)
+func (h *AdminHandler) LookupUser(c *gin.Context) {
+	email := c.Query("email")
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	user, exists := h.userService.GetUserByEmail(email)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	h.respondWithUser(c, user.ID)
+}
+
+// GetUser returns a user and their sessions
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	h.respondWithUser(c, c.Param("id"))
+}
+
+// respondWithUser writes a user's profile together with their sessions
+func (h *AdminHandler) respondWithUser(c *gin.Context, userID string) {
+	user, err := h.userService.GetProfile(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":     user,
+		"sessions": h.authService.GetSessionsByUser(userID),
+	})
+}
+
+// UpdateUserRole changes a user's role
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be user, support or admin"})
+		return
+	}
+
+	user, err := h.userService.SetRole(c.Param("id"), req.Role)
+	if err != nil {
+		if err == services.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// RevokeUserSessions signs a user out of every device
+func (h *AdminHandler) RevokeUserSessions(c *gin.Context) {
+	userID := c.Param("id")
+	if _, exists := h.userService.GetUserByID(userID); !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	revoked := h.authService.RevokeUserSessions(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"revoked": revoked,
+	})
+}
+
+// RevokeUserSession signs a user out of a single session
+func (h *AdminHandler) RevokeUserSession(c *gin.Context) {
+	if err := h.authService.RevokeSession(c.Param("id"), c.Param("sessionId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
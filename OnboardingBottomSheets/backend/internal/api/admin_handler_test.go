@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAdminRouter serves the feedback read endpoints of the admin API to a caller with
+// the given role, with one stored feedback entry containing an email address
+func newAdminRouter(t *testing.T, role string) (*gin.Engine, *models.Feedback) {
+	t.Helper()
+	s := newTestServices(t, newTestLogger())
+	handler := NewAdminHandler(s.feedback, nil, nil, nil, nil, s.users, nil, s.redactor, s.background, s.logger)
+
+	feedback, err := s.feedback.StoreFeedback("user-1", "jane@example.com", models.SubmitFeedbackRequest{
+		Content:  "Contact me at jane@example.com about the crash",
+		Platform: "ios",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	admin := router.Group("/admin", func(c *gin.Context) { c.Set("role", role) })
+	admin.GET("/feedback", handler.ListAllFeedback)
+	admin.GET("/feedback/search", handler.SearchFeedback)
+	admin.GET("/feedback/export", handler.ExportFeedback)
+	admin.GET("/feedback/:id", handler.GetFeedback)
+	return router, feedback
+}
+
+func getFeedbackJSON(t *testing.T, router *gin.Engine, path string) map[string]interface{} {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d: %s", path, w.Code, w.Body.String())
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestAdminFeedbackIsRedactedForSupport(t *testing.T) {
+	for _, tt := range []struct {
+		role     string
+		redacted bool
+	}{
+		{models.RoleSupport, true},
+		{models.RoleAdmin, false},
+	} {
+		router, feedback := newAdminRouter(t, tt.role)
+
+		entries := map[string]map[string]interface{}{
+			"get":    getFeedbackJSON(t, router, "/admin/feedback/"+feedback.ID),
+			"list":   getFeedbackJSON(t, router, "/admin/feedback")["feedback"].([]interface{})[0].(map[string]interface{}),
+			"search": getFeedbackJSON(t, router, "/admin/feedback/search?q=crash")["results"].([]interface{})[0].(map[string]interface{})["feedback"].(map[string]interface{}),
+		}
+		for endpoint, entry := range entries {
+			email, content := entry["email"], entry["content"]
+			if tt.redacted && (email != "[email]" || content != "Contact me at [email] about the crash") {
+				t.Errorf("%s %s: got email %q content %q, want them redacted", tt.role, endpoint, email, content)
+			}
+			if !tt.redacted && (email != feedback.Email || content != feedback.Content) {
+				t.Errorf("%s %s: got email %q content %q, want the original", tt.role, endpoint, email, content)
+			}
+		}
+	}
+}
+
+func TestExportFeedbackRedactsUnlessAnAdminOptsOut(t *testing.T) {
+	tests := []struct {
+		role     string
+		query    string
+		code     int
+		redacted bool
+	}{
+		{models.RoleSupport, "", http.StatusOK, true},
+		{models.RoleSupport, "?redact=false", http.StatusForbidden, false},
+		{models.RoleAdmin, "", http.StatusOK, true},
+		{models.RoleAdmin, "?redact=false", http.StatusOK, false},
+		{models.RoleAdmin, "?redact=maybe", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		router, feedback := newAdminRouter(t, tt.role)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/feedback/export"+tt.query, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s %s = %d, want %d: %s", tt.role, tt.query, w.Code, tt.code, w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("%s %s: got %d records, want a header and one row", tt.role, tt.query, len(records))
+		}
+		email, content := records[1][4], records[1][14]
+		if tt.redacted && (email != "[email]" || content != "Contact me at [email] about the crash") {
+			t.Errorf("%s %s: got email %q content %q, want them redacted", tt.role, tt.query, email, content)
+		}
+		if !tt.redacted && (email != feedback.Email || content != feedback.Content) {
+			t.Errorf("%s %s: got email %q content %q, want the original", tt.role, tt.query, email, content)
+		}
+	}
+}
+
+func TestExportFeedbackFormats(t *testing.T) {
+	router, feedback := newAdminRouter(t, models.RoleAdmin)
+
+	tests := []struct {
+		query       string
+		contentType string
+		filename    string
+	}{
+		{"?from=2024-01-01&to=2024-01-31", "text/csv; charset=utf-8", "feedback-2024-01-01-to-2024-01-31.csv"},
+		{"?format=jsonl&to=2024-01-31T12:00:00Z", "application/x-ndjson", "feedback-start-to-2024-01-31.jsonl"},
+		{"?format=ndjson&from=2024-01-01&to=2024-02-01T00:00:00Z", "application/x-ndjson", "feedback-2024-01-01-to-2024-01-31.ndjson"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/feedback/export"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s = %d: %s", tt.query, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%s: content type %q, want %q", tt.query, got, tt.contentType)
+		}
+		if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="`+tt.filename+`"`; got != want {
+			t.Errorf("%s: disposition %q, want %q", tt.query, got, want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/feedback/export?format=jsonl&redact=false", nil))
+	var exported models.Feedback
+	if err := json.Unmarshal(w.Body.Bytes(), &exported); err != nil {
+		t.Fatalf("JSONL export %q: %v", w.Body.String(), err)
+	}
+	if exported.ID != feedback.ID || strings.Count(w.Body.String(), "\n") != 1 {
+		t.Errorf("JSONL export = %q, want one line for %s", w.Body.String(), feedback.ID)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/feedback/export?format=xlsx", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestExportFilenameNamesTheLastIncludedDay(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	today := time.Now().UTC().Format("2006-01-02")
+
+	tests := []struct {
+		from, to time.Time
+		want     string
+	}{
+		{day(1), day(31).AddDate(0, 0, 1), "feedback-2024-01-01-to-2024-01-31.csv"},
+		{day(1), day(2), "feedback-2024-01-01-to-2024-01-01.csv"},
+		{day(1), day(15).Add(12 * time.Hour), "feedback-2024-01-01-to-2024-01-15.csv"},
+		{time.Time{}, day(10), "feedback-start-to-2024-01-09.csv"},
+		{day(1), time.Time{}, "feedback-2024-01-01-to-" + today + ".csv"},
+	}
+	for _, tt := range tests {
+		if got := exportFilename(tt.from, tt.to, "csv"); got != tt.want {
+			t.Errorf("exportFilename(%v, %v) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestFeedbackAnalyticsWindowsEndAtTheNextDayBoundary(t *testing.T) {
+	s := newTestServices(t, newTestLogger())
+	analytics := services.NewAnalyticsService(s.feedback, s.users, config.AnalyticsConfig{CacheTTL: time.Hour})
+	handler := NewAdminHandler(s.feedback, analytics, nil, nil, nil, s.users, nil, s.redactor, s.background, s.logger)
+	router := gin.New()
+	router.GET("/admin/feedback/analytics", handler.FeedbackAnalytics)
+
+	first := getFeedbackJSON(t, router, "/admin/feedback/analytics?days=7")
+	second := getFeedbackJSON(t, router, "/admin/feedback/analytics?days=7")
+
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if first["to"] != to.Format(time.RFC3339) || first["from"] != to.AddDate(0, 0, -7).Format(time.RFC3339) {
+		t.Errorf("window = %v to %v, want the 7 days ending at %s", first["from"], first["to"], to.Format(time.RFC3339))
+	}
+	if first["generated_at"] != second["generated_at"] {
+		t.Errorf("repeated request was recomputed (%v, then %v), want the cached result", first["generated_at"], second["generated_at"])
+	}
+
+	for _, query := range []string{"days=0", "days=abc", "from=2024-03-02&to=2024-03-01", "from=2023-01-01&to=2024-03-01", "from=yesterday"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/feedback/analytics?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("?%s = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"onboarding-backend/internal/models"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentHandler handles feedback attachment endpoints
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+	feedbackService   *services.FeedbackService
+	slackService      services.SlackService
+	background        *services.Background
+	logger            *slog.Logger
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(
+	attachmentService *services.AttachmentService,
+	feedbackService *services.FeedbackService,
+	slackService services.SlackService,
+	background *services.Background,
+	logger *slog.Logger,
+) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		feedbackService:   feedbackService,
+		slackService:      slackService,
+		background:        background,
+		logger:            logger,
+	}
+}
+
+// UploadAttachment accepts a multipart upload with the file in the "file" field
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	// Leave some room above the file limit for the multipart framing
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAttachmentSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Attachments are limited to %d MB", services.MaxAttachmentSize>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	defer file.Close()
+
+	userID, _ := c.Get("user_id")
+
+	attachment, err := h.attachmentService.Upload(userID.(string), c.Param("id"), fileHeader.Filename, file)
+	if err != nil {
+		switch err {
+		case services.ErrFeedbackNotFound, services.ErrFeedbackNotOwnedByUser:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Feedback not found"})
+		case services.ErrAttachmentType:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Only images (PNG, JPEG, GIF, WebP), text logs and ZIP/GZIP archives are allowed"})
+		case services.ErrAttachmentTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Attachments are limited to %d MB", services.MaxAttachmentSize>>20)})
+		case services.ErrTooManyAttachments:
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("At most %d attachments are allowed per feedback", services.MaxAttachmentsPerFeedback)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment"})
+		}
+		return
+	}
+
+	// Link the attachment in Slack (async to not block response). Attachments of held
+	// feedback are posted when a reviewer approves it.
+	if feedback, exists := h.feedbackService.GetFeedback(attachment.FeedbackID); exists && feedback.ModerationStatus == models.ModerationApproved {
+		slackURL := h.attachmentService.SlackURL(attachment)
+		ctx := context.WithoutCancel(c.Request.Context())
+		h.background.Go(func() {
+			if err := h.slackService.PublishAttachment(ctx, feedback, attachment, slackURL); err != nil {
+				h.logger.ErrorContext(ctx, "failed to publish attachment to slack",
+					"feedback_id", feedback.ID, "attachment_id", attachment.ID, "error", err)
+			}
+		})
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":      true,
+		"attachment":   attachment,
+		"download_url": h.attachmentService.DownloadURL(attachment),
+	})
+}
+
+// DownloadAttachment serves an attachment from a signed link
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachment, rc, err := h.attachmentService.Open(c.Param("id"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch err {
+		case services.ErrInvalidAttachmentLink:
+			c.JSON(http.StatusForbidden, gin.H{"error": "This link is invalid or has expired"})
+		case services.ErrAttachmentNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment"})
+		}
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Length", fmt.Sprintf("%d", attachment.Size))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, attachment.Filename))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, rc)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"onboarding-backend/internal/models"
 	"onboarding-backend/internal/services"
@@ -31,7 +32,7 @@ func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.GenerateMagicLink(req.Email)
+	link, err := h.authService.GenerateMagicLink(c.Request.Context(), req.Email)
 	if err != nil {
 		if err == services.ErrRateLimitExceeded {
 			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests. Please try again later."})
@@ -44,12 +45,15 @@ func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
 	// In production, send email with the link
 	// For now, return the token for testing
 	// Format: yourapp://auth/verify?token=TOKEN
-	magicLink := fmt.Sprintf("onboardingapp://auth/verify?token=%s", token)
+	magicLink := fmt.Sprintf("onboardingapp://auth/verify?token=%s", link.Token)
 
+	// expires_in lets the app count down without trusting the device clock
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Magic link sent to your email",
-		"magic_link": magicLink, // Remove this in production
-		"token":      token,     // Remove this in production
+		"expires_at": link.ExpiresAt,
+		"expires_in": int(time.Until(link.ExpiresAt).Round(time.Second).Seconds()),
+		"magic_link": magicLink,  // Remove this in production
+		"token":      link.Token, // Remove this in production
 	})
 }
 
@@ -83,23 +87,27 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	userID, email, err := h.authService.ValidateJWT(tokenString)
+	claims, err := h.authService.ValidateJWT(tokenString)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		return
 	}
 
 	// Generate new token
-	newToken, err := h.authService.GenerateJWT(userID, email)
+	newToken, user, err := h.authService.RefreshSession(claims)
 	if err != nil {
+		if err == services.ErrUserNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"token":   newToken,
-		"user_id": userID,
-		"email":   email,
+		"user_id": user.ID,
+		"email":   user.Email,
 	})
 }
 
@@ -235,7 +243,7 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		userID, email, err := h.authService.ValidateJWT(tokenString)
+		claims, err := h.authService.ValidateJWT(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
@@ -243,8 +251,27 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Set user info in context
-		c.Set("user_id", userID)
-		c.Set("email", email)
+		c.Set("user_id", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	}
 }
+
+// RequireRole only lets through users who currently hold one of the given roles.
+// It must run after AuthMiddleware.
+func (h *AuthHandler) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		c.Abort()
+	}
+}
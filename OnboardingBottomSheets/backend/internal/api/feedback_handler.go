@@ -1,7 +1,13 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"onboarding-backend/internal/models"
 	"onboarding-backend/internal/services"
@@ -11,29 +17,40 @@ import (
 
 // FeedbackHandler handles feedback endpoints
 type FeedbackHandler struct {
-	feedbackService *services.FeedbackService
-	slackService    services.SlackService
-	authService     *services.AuthService
+	feedbackService   *services.FeedbackService
+	attachmentService *services.AttachmentService
+	slackService      services.SlackService
+	ticketService     *services.TicketService
+	authService       *services.AuthService
+	background        *services.Background
+	logger            *slog.Logger
 }
 
 // NewFeedbackHandler creates a new feedback handler
 func NewFeedbackHandler(
 	feedbackService *services.FeedbackService,
+	attachmentService *services.AttachmentService,
 	slackService services.SlackService,
+	ticketService *services.TicketService,
 	authService *services.AuthService,
+	background *services.Background,
+	logger *slog.Logger,
 ) *FeedbackHandler {
 	return &FeedbackHandler{
-		feedbackService: feedbackService,
-		slackService:    slackService,
-		authService:     authService,
+		feedbackService:   feedbackService,
+		attachmentService: attachmentService,
+		slackService:      slackService,
+		ticketService:     ticketService,
+		authService:       authService,
+		background:        background,
+		logger:            logger,
 	}
 }
 
 // SubmitFeedback handles feedback submission
 func (h *FeedbackHandler) SubmitFeedback(c *gin.Context) {
 	var req models.SubmitFeedbackRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Content is required"})
+	if !bindJSON(c, &req, "Invalid feedback") {
 		return
 	}
 
@@ -45,21 +62,30 @@ func (h *FeedbackHandler) SubmitFeedback(c *gin.Context) {
 	feedback, err := h.feedbackService.StoreFeedback(
 		userID.(string),
 		email.(string),
-		req.Content,
-		req.Platform,
+		req,
 	)
 	if err != nil {
+		if respondWithValidationError(c, err, "Invalid feedback") {
+			return
+		}
+		if err == services.ErrFeedbackRateLimited {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "You're sending feedback too quickly. Please try again later."})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store feedback"})
 		return
 	}
 
-	// Publish to Slack (async to not block response)
-	go func() {
-		if err := h.slackService.PublishFeedback(feedback); err != nil {
-			// Log error but don't fail the request
-			// In production, implement retry logic
-		}
-	}()
+	// Publish to Slack and the tracker (async to not block response). Held feedback waits for review.
+	if feedback.ModerationStatus == models.ModerationApproved {
+		ctx := context.WithoutCancel(c.Request.Context())
+		h.background.Go(func() {
+			if err := h.slackService.PublishFeedback(ctx, feedback); err != nil {
+				h.logger.ErrorContext(ctx, "failed to publish feedback to slack", "feedback_id", feedback.ID, "error", err)
+			}
+		})
+		h.background.Go(func() { h.ticketService.FileIfMatched(ctx, feedback) })
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":     true,
@@ -68,14 +94,188 @@ func (h *FeedbackHandler) SubmitFeedback(c *gin.Context) {
 	})
 }
 
-// ListFeedback returns feedback for the authenticated user
+// ListFeedback returns a page of feedback for the authenticated user, newest first
 func (h *FeedbackHandler) ListFeedback(c *gin.Context) {
+	query, err := parseFeedbackQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	query.UserID = userID.(string)
+
+	respondWithFeedbackPage(c, h.feedbackService, query)
+}
+
+// GetFeedback returns one of the authenticated user's feedback entries with its status and replies
+func (h *FeedbackHandler) GetFeedback(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	feedback, exists := h.feedbackService.GetFeedback(c.Param("id"))
+	if !exists || feedback.UserID != userID.(string) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feedback not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// UpdateFeedback edits the authenticated user's recent feedback
+func (h *FeedbackHandler) UpdateFeedback(c *gin.Context) {
+	var req models.UpdateFeedbackRequest
+	if !bindJSON(c, &req, "Invalid feedback") {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	feedback, err := h.feedbackService.UpdateFeedback(userID.(string), c.Param("id"), req)
+	if err != nil {
+		respondWithFeedbackError(c, err, "Failed to update feedback")
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// DeleteFeedback deletes the authenticated user's recent feedback and its attachments
+func (h *FeedbackHandler) DeleteFeedback(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	feedback, err := h.feedbackService.DeleteFeedback(userID.(string), c.Param("id"))
+	if err != nil {
+		respondWithFeedbackError(c, err, "Failed to delete feedback")
+		return
+	}
+	h.attachmentService.DeleteByFeedback(c.Request.Context(), feedback.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Feedback deleted",
+	})
+}
+
+// ReplyToFeedback adds the authenticated user's reply to the thread on their own feedback
+func (h *FeedbackHandler) ReplyToFeedback(c *gin.Context) {
 	userID, _ := c.Get("user_id")
+	addReply(c, h.feedbackService, userID.(string), models.RoleUser)
+}
+
+// addReply binds a reply request and adds it to the feedback thread
+func addReply(c *gin.Context, feedbackService *services.FeedbackService, authorID, authorRole string) {
+	var req models.FeedbackReplyRequest
+	if !bindJSON(c, &req, "Invalid reply") {
+		return
+	}
+
+	reply, err := feedbackService.AddReply(c.Request.Context(), c.Param("id"), authorID, authorRole, req)
+	if err != nil {
+		if respondWithValidationError(c, err, "Invalid reply") {
+			return
+		}
+		respondWithFeedbackError(c, err, "Failed to add reply")
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
+}
+
+// respondWithFeedbackError maps feedback lifecycle errors to responses
+func respondWithFeedbackError(c *gin.Context, err error, fallback string) {
+	if respondWithValidationError(c, err, "Invalid feedback") {
+		return
+	}
+
+	switch err {
+	case services.ErrFeedbackNotFound, services.ErrFeedbackNotOwnedByUser:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feedback not found"})
+	case services.ErrEditWindowClosed:
+		c.JSON(http.StatusForbidden, gin.H{"error": "The time for changing this feedback has passed"})
+	case services.ErrInvalidTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": "Feedback can't move to that status from its current status"})
+	case services.ErrFeedbackNotHeld:
+		c.JSON(http.StatusConflict, gin.H{"error": "Feedback is not held for review"})
+	case services.ErrReplyNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parent reply not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseFeedbackQuery reads the listing parameters shared by user and admin endpoints:
+// limit, cursor, platform, category, screen, tag, status, moderation, min_rating/max_rating, and a
+// from/to date range (RFC 3339 or YYYY-MM-DD)
+func parseFeedbackQuery(c *gin.Context) (services.FeedbackQuery, error) {
+	query := services.FeedbackQuery{
+		Platform:   c.Query("platform"),
+		Category:   c.Query("category"),
+		Screen:     c.Query("screen"),
+		Tag:        c.Query("tag"),
+		Status:     c.Query("status"),
+		Moderation: c.Query("moderation"),
+		Cursor:     c.Query("cursor"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return query, errors.New("limit must be a positive number")
+		}
+		query.Limit = n
+	}
+
+	for param, target := range map[string]*int{"min_rating": &query.MinRating, "max_rating": &query.MaxRating} {
+		if value := c.Query(param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 5 {
+				return query, fmt.Errorf("%s must be between 1 and 5", param)
+			}
+			*target = n
+		}
+	}
+
+	var err error
+	if query.From, err = parseDateParam(c.Query("from"), false); err != nil {
+		return query, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+	if query.To, err = parseDateParam(c.Query("to"), true); err != nil {
+		return query, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+
+	return query, nil
+}
 
-	feedback := h.feedbackService.GetFeedbackByUser(userID.(string))
+// parseDateParam parses an RFC 3339 time or a YYYY-MM-DD date. A bare date used as
+// an upper bound covers that whole day.
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// respondWithFeedbackPage runs a listing query and writes the page
+func respondWithFeedbackPage(c *gin.Context, feedbackService *services.FeedbackService, query services.FeedbackQuery) {
+	page, err := feedbackService.ListFeedback(query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"feedback": feedback,
-		"count":    len(feedback),
+		"feedback":    page.Feedback,
+		"count":       len(page.Feedback),
+		"next_cursor": page.NextCursor,
 	})
 }
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/logging"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newFeedbackRouter serves feedback submission for a signed-in user, publishing to a
+// mock Slack service
+func newFeedbackRouter(t *testing.T) (*gin.Engine, *services.MockSlackService, *services.Background) {
+	t.Helper()
+	return newLoggedFeedbackRouter(t, newTestLogger())
+}
+
+// newLoggedFeedbackRouter is newFeedbackRouter writing logs to logger, with request IDs
+func newLoggedFeedbackRouter(t *testing.T, logger *slog.Logger) (*gin.Engine, *services.MockSlackService, *services.Background) {
+	t.Helper()
+	s := newTestServices(t, logger)
+	routing, err := services.SlackRoutingFromConfig(config.SlackConfig{DefaultChannel: "#feedback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slack := services.NewMockSlackService(s.redactor, routing, s.feedback.GetFeedback, logger)
+	tickets := services.NewTicketService(nil, nil, s.feedback, s.redactor, logger)
+	handler := NewFeedbackHandler(s.feedback, nil, slack, tickets, nil, s.background, logger)
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.POST("/feedback", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Set("email", c.GetHeader("X-Test-User")+"@example.com")
+	}, handler.SubmitFeedback)
+	return router, slack, s.background
+}
+
+// postFeedback submits feedback as user and returns the response
+func postFeedback(router *gin.Engine, user, content string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader(fmt.Sprintf(`{"content":%q,"platform":"ios"}`, content)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", user)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func submitFeedback(t *testing.T, router *gin.Engine, user, content string) {
+	t.Helper()
+	if w := postFeedback(router, user, content); w.Code != http.StatusOK {
+		t.Fatalf("submit = %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// drain waits for background publishing to finish
+func drain(t *testing.T, background *services.Background) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if running, err := background.Wait(ctx); err != nil {
+		t.Fatalf("%d background tasks still running: %v", running, err)
+	}
+}
+
+func TestSubmitFeedbackPublishesToSlack(t *testing.T) {
+	router, slack, background := newFeedbackRouter(t)
+	slack.SetLatency(10 * time.Millisecond)
+
+	// Concurrent submissions exercise the mock under -race
+	const n = 8
+	codes := make(chan int, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			codes <- postFeedback(router, fmt.Sprintf("user-%d", i), fmt.Sprintf("Feedback number %d", i)).Code
+		}(i)
+	}
+	for i := 0; i < n; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("submit = %d, want 200", code)
+		}
+	}
+
+	messages, err := slack.WaitForMessages(n, 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForMessages: %v (got %d)", err, len(messages))
+	}
+	if got := len(slack.MessagesTo("#feedback")); got != n {
+		t.Errorf("%d messages sent to #feedback, want %d", got, n)
+	}
+	for _, message := range messages {
+		if strings.Contains(message.Text, "@example.com") {
+			t.Errorf("message contains an email address: %s", message.Text)
+		}
+	}
+	drain(t, background)
+}
+
+func TestSubmitFeedbackDoesNotRecordFailedSlackSends(t *testing.T) {
+	router, slack, background := newFeedbackRouter(t)
+
+	slack.FailNext(1, nil)
+	submitFeedback(t, router, "user-1", "First feedback")
+	slack.SetFailure(services.ErrMockSlackFailure)
+	submitFeedback(t, router, "user-2", "Second feedback")
+	drain(t, background)
+
+	if messages := slack.GetMessages(); len(messages) != 0 {
+		t.Fatalf("failed sends were recorded: %v", messages)
+	}
+	if _, err := slack.WaitForMessages(1, 50*time.Millisecond); err != services.ErrSlackWaitTimeout {
+		t.Errorf("WaitForMessages = %v, want ErrSlackWaitTimeout", err)
+	}
+}
+
+func TestSubmitFeedbackLogsSlackPublishingWithRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	logger := logging.New(&logs, config.LoggingConfig{Level: "info", Format: config.LogFormatJSON}, nil)
+	router, slack, background := newLoggedFeedbackRouter(t, logger)
+
+	submit := func(requestID, content string) string {
+		req := httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader(fmt.Sprintf(`{"content":%q,"platform":"ios"}`, content)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", "user-1")
+		req.Header.Set(RequestIDHeader, requestID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp struct {
+			FeedbackID string `json:"feedback_id"`
+		}
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil {
+			t.Fatalf("submit = %d: %s", w.Code, w.Body.String())
+		}
+		return resp.FeedbackID
+	}
+	submit("req-sent", "First feedback")
+	if _, err := slack.WaitForMessages(1, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	slack.SetFailure(services.ErrMockSlackFailure)
+	failedID := submit("req-failed", "Second feedback")
+	drain(t, background)
+
+	records := map[string]map[string]interface{}{}
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("invalid log line %s: %v", line, err)
+		}
+		records[record["msg"].(string)] = record
+	}
+
+	if sent := records["mock slack message sent"]; sent == nil || sent["request_id"] != "req-sent" {
+		t.Errorf("slack send log = %v, want request_id req-sent", sent)
+	}
+	failed := records["failed to publish feedback to slack"]
+	if failed == nil {
+		t.Fatalf("failed publish was not logged:\n%s", logs.String())
+	}
+	if failed["request_id"] != "req-failed" || failed["feedback_id"] != failedID || failed["level"] != "ERROR" {
+		t.Errorf("failed publish log = %v, want request_id req-failed and feedback_id %s", failed, failedID)
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testBaseURL is the public URL links point at in tests
+const testBaseURL = "http://localhost:8080"
+
+// newTestLogger returns a logger that discards its output
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// testServices are the core services wired as main does, for handler tests
+type testServices struct {
+	logger     *slog.Logger
+	redactor   *services.Redactor
+	background *services.Background
+	email      *services.EmailService
+	webhooks   *services.WebhookService
+	users      *services.UserService
+	feedback   *services.FeedbackService
+}
+
+// newTestServices wires email, webhook, user and feedback services logging to logger.
+// Email is logged instead of sent and feedback uses no moderation checks.
+func newTestServices(t *testing.T, logger *slog.Logger) *testServices {
+	t.Helper()
+	redactor, err := services.NewRedactor("email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServices{
+		logger:     logger,
+		redactor:   redactor,
+		background: services.NewBackground(),
+		email:      services.NewEmailService(config.EmailConfig{}, logger),
+	}
+	s.webhooks = services.NewWebhookService(redactor, config.WebhooksConfig{}, s.background, logger)
+	s.users = services.NewUserService(s.email, s.webhooks, config.RolesConfig{}, config.Default().Accounts, testBaseURL, logger)
+	s.feedback = services.NewFeedbackService(s.users, s.email, s.webhooks, config.Default().Feedback, logger)
+	return s
+}
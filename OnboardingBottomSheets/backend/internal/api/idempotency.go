@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotencyKeyLength caps the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// replayableStatus reports whether a response can be replayed for a repeated request.
+// Successes are, as are 400 and 422 since the same body would fail the same way. Other
+// failures, such as rate limiting, timeouts or server errors, may succeed on retry.
+func replayableStatus(status int) bool {
+	switch {
+	case status >= 200 && status < 300:
+		return true
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return true
+	default:
+		return false
+	}
+}
+
+// responseRecorder copies everything written to the response so it can be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the original response when an authenticated user repeats a
+// request with the same Idempotency-Key header. Requests without the header run normally.
+// Must run after AuthMiddleware.
+func Idempotency(idempotencyService *services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are per user and per endpoint
+		userID := c.GetString("user_id")
+		scopedKey := userID + "\x00" + c.Request.Method + " " + c.FullPath() + "\x00" + key
+		hash := sha256.Sum256(body)
+
+		stored, err := idempotencyService.Begin(userID, scopedKey, hex.EncodeToString(hash[:]))
+		switch err {
+		case nil:
+		case services.ErrIdempotencyKeyInUse:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			return
+		case services.ErrIdempotencyKeyMismatch:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			return
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+			return
+		}
+
+		if stored != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// Release the key if the handler panics or fails in a way a retry might fix
+		completed := false
+		defer func() {
+			if !completed {
+				idempotencyService.Release(scopedKey)
+			}
+		}()
+
+		c.Next()
+
+		if replayableStatus(recorder.Status()) {
+			idempotencyService.Complete(scopedKey, &services.IdempotentResponse{
+				Status:      recorder.Status(),
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+			completed = true
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotentRouter serves POST /submit through the Idempotency middleware, answering
+// with the given statuses in turn
+func newIdempotentRouter(statuses ...int) (*gin.Engine, *int) {
+	calls := 0
+	router := gin.New()
+	router.POST("/submit", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+	}, Idempotency(services.NewIdempotencyService(config.Default().Idempotency)), func(c *gin.Context) {
+		status := statuses[min(calls, len(statuses)-1)]
+		calls++
+		c.JSON(status, gin.H{"call": calls})
+	})
+	return router, &calls
+}
+
+func postWithKey(router *gin.Engine, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(`{"content":"hi"}`))
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysDeterministicResponses(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusCreated, http.StatusBadRequest, http.StatusUnprocessableEntity} {
+		router, calls := newIdempotentRouter(status, http.StatusOK)
+
+		first := postWithKey(router, "key")
+		second := postWithKey(router, "key")
+
+		if *calls != 1 {
+			t.Errorf("status %d: handler ran %d times, want 1", status, *calls)
+		}
+		if second.Code != status || second.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("status %d: repeat got %d (replayed %q), want a replay", status, second.Code, second.Header().Get("Idempotent-Replayed"))
+		}
+		if second.Body.String() != first.Body.String() {
+			t.Errorf("status %d: replayed body %q, want %q", status, second.Body.String(), first.Body.String())
+		}
+	}
+}
+
+func TestIdempotencyReleasesKeyForRetryableFailures(t *testing.T) {
+	for _, status := range []int{
+		http.StatusRequestTimeout,
+		http.StatusConflict,
+		http.StatusRequestEntityTooLarge,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusServiceUnavailable,
+	} {
+		router, calls := newIdempotentRouter(status, http.StatusOK)
+
+		postWithKey(router, "key")
+		retry := postWithKey(router, "key")
+
+		if *calls != 2 {
+			t.Errorf("status %d: handler ran %d times, want the retry to run", status, *calls)
+		}
+		if retry.Code != http.StatusOK || retry.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("status %d: retry got %d (replayed %q), want a fresh 200", status, retry.Code, retry.Header().Get("Idempotent-Replayed"))
+		}
+	}
+}
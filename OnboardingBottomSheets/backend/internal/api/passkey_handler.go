@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+
+	"onboarding-backend/internal/models"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasskeyHandler handles WebAuthn passkey endpoints
+type PasskeyHandler struct {
+	passkeyService *services.PasskeyService
+}
+
+// NewPasskeyHandler creates a new passkey handler
+func NewPasskeyHandler(passkeyService *services.PasskeyService) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+	}
+}
+
+// BeginRegistration returns credential creation options for the authenticated user
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	sessionID, options, err := h.passkeyService.BeginRegistration(userID.(string))
+	if err != nil {
+		if err == services.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"options":    options,
+	})
+}
+
+// FinishRegistration verifies the authenticator response and stores the passkey.
+// The body is the PublicKeyCredential JSON returned by navigator.credentials.create().
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	credential, err := h.passkeyService.FinishRegistration(userID.(string), c.Query("session_id"), c.Request.Body)
+	if err != nil {
+		if err == services.ErrPasskeySessionNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Registration session expired. Please try again."})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Passkey registration failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"credential": credential,
+	})
+}
+
+// ListCredentials returns the passkeys registered by the authenticated user
+func (h *PasskeyHandler) ListCredentials(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	credentials := h.passkeyService.GetCredentials(userID.(string))
+
+	c.JSON(http.StatusOK, gin.H{
+		"credentials": credentials,
+		"count":       len(credentials),
+	})
+}
+
+// BeginLogin returns credential request options for a passkey login
+func (h *PasskeyHandler) BeginLogin(c *gin.Context) {
+	var req models.BeginPasskeyLoginRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+			return
+		}
+	}
+
+	sessionID, options, err := h.passkeyService.BeginLogin(req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"options":    options,
+	})
+}
+
+// FinishLogin verifies the assertion and returns the normal auth response.
+// The body is the PublicKeyCredential JSON returned by navigator.credentials.get().
+func (h *PasskeyHandler) FinishLogin(c *gin.Context) {
+	authResponse, err := h.passkeyService.FinishLogin(c.Query("session_id"), c.Request.Body)
+	if err != nil {
+		switch err {
+		case services.ErrPasskeySessionNotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Login session expired. Please try again."})
+		case services.ErrPasskeyCloned:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "This passkey can no longer be trusted. Please sign in with email."})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey verification failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, authResponse)
+}
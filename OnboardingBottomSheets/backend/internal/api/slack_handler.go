@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSlackPayloadSize caps the size of an interaction request body
+const maxSlackPayloadSize = 1 << 20
+
+// SlackHandler handles requests sent by Slack
+type SlackHandler struct {
+	interactionService *services.SlackInteractionService
+	background         *services.Background
+	logger             *slog.Logger
+}
+
+// NewSlackHandler creates a new Slack handler
+func NewSlackHandler(interactionService *services.SlackInteractionService, background *services.Background, logger *slog.Logger) *SlackHandler {
+	return &SlackHandler{
+		interactionService: interactionService,
+		background:         background,
+		logger:             logger,
+	}
+}
+
+// HandleInteraction receives button clicks on feedback messages. The request must carry
+// a valid Slack signature; the reply is shown only to the Slack user who clicked.
+// Slow actions are acknowledged at once and their result is posted to the response_url.
+func (h *SlackHandler) HandleInteraction(c *gin.Context) {
+	if !h.interactionService.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Slack interactions are not configured"})
+		return
+	}
+
+	// The signature covers the raw body, so read it before any parsing
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSlackPayloadSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Payload too large"})
+		return
+	}
+
+	err = h.interactionService.Verify(c.GetHeader("X-Slack-Request-Timestamp"), c.GetHeader("X-Slack-Signature"), body)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Slack signature"})
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+	var interaction services.SlackInteraction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+
+	if interaction.Deferred() {
+		// The work outlives the request, but its logs keep the request ID
+		ctx := context.WithoutCancel(c.Request.Context())
+		started := h.background.Go(func() {
+			text, ok := h.runAction(ctx, &interaction)
+			if !ok {
+				return
+			}
+			if err := h.interactionService.Respond(ctx, interaction.ResponseURL, text); err != nil {
+				h.logger.ErrorContext(ctx, "failed to post slack action result", "error", err)
+			}
+		})
+		if !started {
+			c.JSON(http.StatusOK, slackEphemeral("⚠️ The server is restarting, please try again"))
+			return
+		}
+		c.Status(http.StatusOK)
+		return
+	}
+
+	text, ok := h.runAction(c.Request.Context(), &interaction)
+	if !ok {
+		// Acknowledge interactions we don't act on so Slack doesn't show an error
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, slackEphemeral(text))
+}
+
+// runAction applies an interaction and returns the message for the Slack user. ok is
+// false for interactions that aren't acted on.
+func (h *SlackHandler) runAction(ctx context.Context, interaction *services.SlackInteraction) (text string, ok bool) {
+	text, err := h.interactionService.HandleInteraction(ctx, interaction)
+	if err != nil {
+		if err == services.ErrUnknownSlackAction {
+			return "", false
+		}
+		return h.slackActionErrorMessage(ctx, err), true
+	}
+	return text, true
+}
+
+// slackEphemeral is a message shown only to the Slack user who took an action
+func slackEphemeral(text string) gin.H {
+	return gin.H{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             text,
+	}
+}
+
+// slackActionErrorMessage explains to the Slack user why an action didn't apply
+func (h *SlackHandler) slackActionErrorMessage(ctx context.Context, err error) string {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return "⚠️ The reply is empty or too long"
+	case err == services.ErrFeedbackNotFound:
+		return "⚠️ This feedback no longer exists"
+	case err == services.ErrInvalidTransition:
+		return "⚠️ This feedback can't be marked resolved from its current status"
+	case err == services.ErrTicketExists:
+		return "⚠️ This feedback already has a ticket"
+	case err == services.ErrTicketInProgress:
+		return "⚠️ A ticket is already being created"
+	case err == services.ErrTicketingDisabled:
+		return "⚠️ Ticketing is not configured"
+	}
+	h.logger.ErrorContext(ctx, "slack action failed", "error", err)
+	return "⚠️ Something went wrong, please try again"
+}
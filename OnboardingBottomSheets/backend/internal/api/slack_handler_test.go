@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/models"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSlackSecret = "signing-secret"
+
+// slackTestEnv is a Slack interactions endpoint backed by a slow fake tracker, with a
+// fake response_url that records what is posted to it
+type slackTestEnv struct {
+	router     *gin.Engine
+	background *services.Background
+	feedback   *models.Feedback
+	responses  chan map[string]interface{}
+	respondURL string
+	release    chan struct{} // closed by answer to let the tracker respond
+	once       sync.Once
+}
+
+// answer lets the tracker respond to pending requests
+func (env *slackTestEnv) answer() {
+	env.once.Do(func() { close(env.release) })
+}
+
+func newSlackTestEnv(t *testing.T) *slackTestEnv {
+	t.Helper()
+	env := &slackTestEnv{
+		responses: make(chan map[string]interface{}, 4),
+		release:   make(chan struct{}),
+	}
+
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-env.release
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"html_url": "https://github.example/acme/app/issues/1"}`))
+	}))
+	t.Cleanup(tracker.Close)
+	// Cleanups run last-in first-out, so the tracker is released before it is closed
+	t.Cleanup(env.answer)
+
+	responseURL := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		env.responses <- body
+	}))
+	t.Cleanup(responseURL.Close)
+	env.respondURL = responseURL.URL
+
+	s := newTestServices(t, newTestLogger())
+	env.background = s.background
+	ticketService := services.NewTicketService(services.NewGitHubTicketSink(tracker.URL, "acme/app", "", nil), nil, s.feedback, s.redactor, s.logger)
+	interactions := services.NewSlackInteractionService(testSlackSecret, s.feedback, ticketService)
+	if err := interactions.SetResponseOrigin(responseURL.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	env.feedback, err = s.feedback.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "It crashes", Platform: "ios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.router = gin.New()
+	env.router.POST("/slack/interactions", NewSlackHandler(interactions, env.background, s.logger).HandleInteraction)
+	return env
+}
+
+// click sends a signed block_actions interaction for the given action on the feedback
+func (env *slackTestEnv) click(actionID string) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": "U1", "username": "sam"},
+		"actions":      []map[string]string{{"action_id": actionID, "value": env.feedback.ID}},
+		"response_url": env.respondURL,
+	})
+	body := "payload=" + url.QueryEscape(string(payload))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSlackSecret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSlackTicketActionIsAcknowledgedBeforeTheTrackerAnswers(t *testing.T) {
+	env := newSlackTestEnv(t)
+
+	w := env.click(services.SlackActionTicket)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Fatalf("ack = %d %q, want an empty 200 while the tracker is still working", w.Code, w.Body.String())
+	}
+	select {
+	case body := <-env.responses:
+		t.Fatalf("result posted before the ticket was created: %v", body)
+	default:
+	}
+
+	env.answer()
+	select {
+	case body := <-env.responses:
+		if body["text"] != "🎫 Ticket created: https://github.example/acme/app/issues/1" || body["response_type"] != "ephemeral" {
+			t.Errorf("response_url got %v, want the ticket URL as an ephemeral message", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no result posted to the response_url")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if running, err := env.background.Wait(ctx); err != nil {
+		t.Errorf("%d background tasks still running: %v", running, err)
+	}
+}
+
+func TestSlackResolveActionAnswersInline(t *testing.T) {
+	env := newSlackTestEnv(t)
+
+	w := env.click(services.SlackActionResolve)
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusOK || !strings.Contains(body["text"].(string), "marked resolved") {
+		t.Errorf("response = %d %v, want the result inline", w.Code, body)
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"onboarding-backend/internal/models"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandler handles self-service account endpoints
+type UserHandler struct {
+	userService    *services.UserService
+	accountService *services.AccountService
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userService *services.UserService, accountService *services.AccountService) *UserHandler {
+	return &UserHandler{
+		userService:    userService,
+		accountService: accountService,
+	}
+}
+
+// GetMe returns the authenticated user's profile
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	user, err := h.userService.GetProfile(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe updates the authenticated user's profile
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile data"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	user, err := h.userService.UpdateProfile(userID.(string), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidLocale:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid locale"})
+		case services.ErrInvalidTimezone:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+		case services.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// CompleteOnboarding records that the authenticated user finished the onboarding flow
+func (h *UserHandler) CompleteOnboarding(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	user, err := h.userService.CompleteOnboarding(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// RequestEmailChange sends a verification link to the new address
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.userService.RequestEmailChange(c.Request.Context(), userID.(string), req.Email); err != nil {
+		switch err {
+		case services.ErrEmailInUse:
+			c.JSON(http.StatusConflict, gin.H{"error": "This email is already in use"})
+		case services.ErrEmailUnchanged:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This is already your email"})
+		case services.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send confirmation email"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Confirmation link sent to your new email",
+	})
+}
+
+// ConfirmEmailChangeWeb handles the confirmation link clicked from the new address
+func (h *UserHandler) ConfirmEmailChangeWeb(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	_, err := h.userService.ConfirmEmailChange(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		message := "This link is invalid or has expired. Please request the email change again."
+		if err == services.ErrEmailInUse {
+			message = "This email address is already in use by another account."
+		}
+		c.Writer.WriteHeader(http.StatusBadRequest)
+		c.Writer.WriteString(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Email Not Changed</title>
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; padding: 20px; text-align: center;">
+    <h1 style="color: #d32f2f;">Email Not Changed</h1>
+    <p>` + message + `</p>
+</body>
+</html>
+		`)
+		return
+	}
+
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.WriteString(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Email Updated</title>
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; padding: 20px; text-align: center;">
+    <h1 style="color: #2A75CF;">Email Updated</h1>
+    <p>Your account now uses this email address. You can return to the app.</p>
+</body>
+</html>
+	`)
+}
+
+// DeleteMe deletes the authenticated user's account in two steps. A request without a
+// confirmation token returns one; sending it back schedules the deletion.
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	var req models.DeleteAccountRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if req.ConfirmationToken == "" {
+		token, expiresAt, err := h.accountService.RequestDeletion(userID.(string))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":            "Send this confirmation token back to delete your account",
+			"confirmation_token": token,
+			"expires_at":         expiresAt,
+		})
+		return
+	}
+
+	deleteAt, err := h.accountService.ConfirmDeletion(c.Request.Context(), userID.(string), req.ConfirmationToken)
+	if err != nil {
+		if err == services.ErrInvalidConfirmation {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                "Your account will be deleted. Sign in again before then to keep it.",
+		"deletion_scheduled_for": deleteAt,
+	})
+}
+
+// ExportMe returns all data stored about the authenticated user as JSON or a ZIP archive
+func (h *UserHandler) ExportMe(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	export, err := h.accountService.ExportData(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	filename := fmt.Sprintf("account-export-%s", export.ExportedAt.Format("2006-01-02"))
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.JSON(http.StatusOK, export)
+	case "zip":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+		c.Header("Content-Type", "application/zip")
+		c.Status(http.StatusOK)
+		if err := services.WriteExportZip(c.Writer, export); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be json or zip"})
+	}
+}
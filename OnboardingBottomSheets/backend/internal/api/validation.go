@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxFeedbackBodySize caps the request body of feedback submissions, edits and replies
+const MaxFeedbackBodySize = 64 << 10
+
+func init() {
+	// Report binding errors under JSON field names rather than Go struct field names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// MaxBodySize rejects request bodies larger than limit bytes
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// bindJSON binds the request body into obj. On failure it writes a field-level
+// error response and returns false.
+func bindJSON(c *gin.Context, obj interface{}, message string) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+	case errors.As(err, &validationErrs):
+		fields := make(map[string]string)
+		for _, fe := range validationErrs {
+			field, _, isItem := strings.Cut(fe.Field(), "[")
+			if _, exists := fields[field]; !exists {
+				fields[field] = bindingErrorMessage(fe, isItem)
+			}
+		}
+		respondWithFieldErrors(c, message, fields)
+	case errors.As(err, &typeErr):
+		respondWithFieldErrors(c, message, map[string]string{
+			typeErr.Field: fmt.Sprintf("must be a %s", typeErr.Type.Kind()),
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+	}
+	return false
+}
+
+// bindingErrorMessage describes a failed binding rule for the app to show inline
+func bindingErrorMessage(fe validator.FieldError, isItem bool) string {
+	prefix := ""
+	if isItem {
+		prefix = "each item "
+	}
+
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice:
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("%smust be at least %s%s", prefix, fe.Param(), unit)
+	case "max":
+		return fmt.Sprintf("%smust be at most %s%s", prefix, fe.Param(), unit)
+	case "oneof":
+		return fmt.Sprintf("%smust be one of %s", prefix, strings.ReplaceAll(fe.Param(), " ", ", "))
+	default:
+		return prefix + "is invalid"
+	}
+}
+
+// respondWithFieldErrors writes a 400 with a message per invalid field
+func respondWithFieldErrors(c *gin.Context, message string, fields map[string]string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  message,
+		"fields": fields,
+	})
+}
+
+// respondWithValidationError writes a service validation error as field-level JSON.
+// It returns false if err isn't a validation error.
+func respondWithValidationError(c *gin.Context, err error, message string) bool {
+	var verr *services.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	respondWithFieldErrors(c, message, verr.Fields)
+	return true
+}
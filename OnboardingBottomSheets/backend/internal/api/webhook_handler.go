@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"onboarding-backend/internal/models"
+	"onboarding-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultDeliveryPageSize and maxDeliveryPageSize bound delivery log listings
+	defaultDeliveryPageSize = 50
+	maxDeliveryPageSize     = 200
+)
+
+// WebhookHandler handles admin endpoints for webhook subscriptions and their delivery log
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook registers an endpoint. The response is the only time the signing secret is shown.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if !bindJSON(c, &req, "Invalid webhook") {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	sub, err := h.webhookService.CreateSubscription(userID.(string), req)
+	if err != nil {
+		switch {
+		case err == services.ErrInvalidWebhookURL:
+			respondWithFieldErrors(c, "Invalid webhook", map[string]string{"url": "must be an absolute https URL"})
+		case errors.Is(err, services.ErrUnknownWebhookEvent):
+			respondWithFieldErrors(c, "Invalid webhook", map[string]string{"events": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListWebhooks returns all webhook subscriptions
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subs := h.webhookService.ListSubscriptions()
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": subs,
+		"count":    len(subs),
+		"events":   services.WebhookEvents,
+	})
+}
+
+// GetWebhook returns a webhook subscription
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	sub, err := h.webhookService.GetSubscription(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// DeleteWebhook removes a webhook subscription
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhookService.DeleteSubscription(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// ListDeliveries returns a webhook's most recent deliveries (?limit=, default 50)
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	limit := defaultDeliveryPageSize
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, maxDeliveryPageSize)
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Param("id"), limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// GetDelivery returns one delivery with all of its attempts
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	delivery, err := h.webhookService.GetDelivery(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver sends a delivery's payload again
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	delivery, err := h.webhookService.Redeliver(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		switch err {
+		case services.ErrWebhookNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		default:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
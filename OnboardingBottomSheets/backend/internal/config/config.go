@@ -0,0 +1,535 @@
+// Package config loads and validates the application configuration.
+//
+// Settings are read in order of increasing precedence: built-in defaults, an optional
+// YAML or TOML file, environment variables, then command-line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Environments the server can run in
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Log output formats
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// defaultJWTSecret is only acceptable outside production
+const defaultJWTSecret = "your-secret-key-change-in-production"
+
+// Config is the complete application configuration. Each field's key tag is its name in
+// a config file (under its section) and its env tag the environment variable.
+type Config struct {
+	Server      ServerConfig      `key:"server"`
+	Logging     LoggingConfig     `key:"logging"`
+	Auth        AuthConfig        `key:"auth"`
+	Email       EmailConfig       `key:"email"`
+	Passkeys    PasskeyConfig     `key:"passkeys"`
+	Roles       RolesConfig       `key:"roles"`
+	Accounts    AccountsConfig    `key:"accounts"`
+	Feedback    FeedbackConfig    `key:"feedback"`
+	Idempotency IdempotencyConfig `key:"idempotency"`
+	Analytics   AnalyticsConfig   `key:"analytics"`
+	Attachments AttachmentsConfig `key:"attachments"`
+	Redaction   RedactionConfig   `key:"redaction"`
+	Slack       SlackConfig       `key:"slack"`
+	Tickets     TicketsConfig     `key:"tickets"`
+	Webhooks    WebhooksConfig    `key:"webhooks"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Environment string   `key:"environment" env:"APP_ENV"`
+	Port        int      `key:"port" env:"PORT"`
+	BaseURL     string   `key:"base_url" env:"BASE_URL"` // used in links sent by email
+	CORSOrigins []string `key:"cors_origins" env:"CORS_ORIGINS"`
+
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `key:"read_timeout" env:"SERVER_READ_TIMEOUT"`   // whole request, including uploads
+	WriteTimeout      time.Duration `key:"write_timeout" env:"SERVER_WRITE_TIMEOUT"` // lifted for exports
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout   time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"` // drain deadline after SIGTERM
+}
+
+// LoggingConfig configures the structured logger
+type LoggingConfig struct {
+	Level  string `key:"level" env:"LOG_LEVEL"`   // debug, info, warn or error
+	Format string `key:"format" env:"LOG_FORMAT"` // json or text
+	Redact bool   `key:"redact" env:"LOG_REDACT"` // hide secrets and personal data; development only when off
+}
+
+// AuthConfig configures sign-in and access tokens
+type AuthConfig struct {
+	JWTSecret         string        `key:"jwt_secret" env:"JWT_SECRET"`
+	JWTLifetime       time.Duration `key:"jwt_lifetime" env:"JWT_LIFETIME"` // also how long a session lasts without a refresh
+	MagicLinkTTL      time.Duration `key:"magic_link_ttl" env:"MAGIC_LINK_TTL"`
+	MagicLinkBurst    int           `key:"magic_link_burst" env:"MAGIC_LINK_BURST"`       // links an address can request at once
+	MagicLinkInterval time.Duration `key:"magic_link_interval" env:"MAGIC_LINK_INTERVAL"` // time to earn back one request
+}
+
+// EmailConfig configures outgoing email. Email is printed to the console when no SMTP
+// credentials are set.
+type EmailConfig struct {
+	SMTPHost     string `key:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `key:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `key:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `key:"smtp_password" env:"SMTP_PASSWORD"`
+	FromEmail    string `key:"from_email" env:"FROM_EMAIL"`
+	FromName     string `key:"from_name" env:"FROM_NAME"`
+}
+
+// PasskeyConfig configures the WebAuthn relying party
+type PasskeyConfig struct {
+	RPID      string   `key:"rp_id" env:"WEBAUTHN_RP_ID"`
+	RPName    string   `key:"rp_name" env:"WEBAUTHN_RP_NAME"`
+	RPOrigins []string `key:"rp_origins" env:"WEBAUTHN_RP_ORIGINS"` // defaults to the base URL
+}
+
+// RolesConfig lists addresses granted staff roles when they sign in
+type RolesConfig struct {
+	AdminEmails   []string `key:"admin_emails" env:"ADMIN_EMAILS"`
+	SupportEmails []string `key:"support_emails" env:"SUPPORT_EMAILS"`
+}
+
+// AccountsConfig configures email changes and account deletion
+type AccountsConfig struct {
+	EmailChangeTTL          time.Duration `key:"email_change_ttl" env:"EMAIL_CHANGE_TTL"`                           // verification link for a new address
+	DeletionConfirmationTTL time.Duration `key:"deletion_confirmation_ttl" env:"ACCOUNT_DELETION_CONFIRMATION_TTL"` // time to confirm a deletion request
+	DeletionGracePeriod     time.Duration `key:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD"`         // signing in before it ends cancels the deletion
+}
+
+// FeedbackConfig configures feedback moderation and editing
+type FeedbackConfig struct {
+	BlockedWords      []string      `key:"blocked_words" env:"FEEDBACK_BLOCKED_WORDS"`
+	MaxLinks          int           `key:"max_links" env:"FEEDBACK_MAX_LINKS"`
+	RateLimitBurst    int           `key:"rate_limit_burst" env:"FEEDBACK_RATE_LIMIT_BURST"`       // submissions a user can make at once
+	RateLimitInterval time.Duration `key:"rate_limit_interval" env:"FEEDBACK_RATE_LIMIT_INTERVAL"` // time to earn back one submission
+	DuplicateWindow   time.Duration `key:"duplicate_window" env:"FEEDBACK_DUPLICATE_WINDOW"`       // how far back identical feedback is a duplicate
+	EditWindow        time.Duration `key:"edit_window" env:"FEEDBACK_EDIT_WINDOW"`                 // how long after submitting it can be edited or deleted
+}
+
+// IdempotencyConfig configures replay of requests sent with an Idempotency-Key
+type IdempotencyConfig struct {
+	KeyTTL time.Duration `key:"key_ttl" env:"IDEMPOTENCY_KEY_TTL"`
+}
+
+// AnalyticsConfig configures the admin analytics endpoint
+type AnalyticsConfig struct {
+	CacheTTL time.Duration `key:"cache_ttl" env:"ANALYTICS_CACHE_TTL"`
+}
+
+// AttachmentsConfig configures attachment storage and download links
+type AttachmentsConfig struct {
+	Dir          string        `key:"dir" env:"ATTACHMENTS_DIR"`
+	SigningKey   string        `key:"signing_key" env:"ATTACHMENT_SIGNING_KEY"`       // defaults to the JWT secret
+	LinkTTL      time.Duration `key:"link_ttl" env:"ATTACHMENT_LINK_TTL"`             // links handed to the uploader
+	SlackLinkTTL time.Duration `key:"slack_link_ttl" env:"ATTACHMENT_SLACK_LINK_TTL"` // links posted to Slack for triage
+}
+
+// RedactionConfig selects the personal data removed from integrations, exports and logs
+type RedactionConfig struct {
+	Kinds []string `key:"kinds" env:"PII_REDACTION"` // email, phone, card, token, or none
+}
+
+// SlackConfig configures the Slack integration
+type SlackConfig struct {
+	SigningSecret  string `key:"signing_secret" env:"SLACK_SIGNING_SECRET"`
+	Routes         string `key:"routes" env:"SLACK_ROUTES"`
+	DefaultChannel string `key:"default_channel" env:"SLACK_DEFAULT_CHANNEL"`
+	DigestChannel  string `key:"digest_channel" env:"SLACK_DIGEST_CHANNEL"` // defaults to the default channel
+	DigestHour     int    `key:"digest_hour" env:"SLACK_DIGEST_HOUR"`       // UTC
+
+	FlushDigestOnShutdown bool `key:"flush_digest_on_shutdown" env:"SLACK_FLUSH_DIGEST_ON_SHUTDOWN"` // post pending entries early rather than drop them
+}
+
+// TicketsConfig configures the issue tracker integration. Ticketing is disabled when
+// Repo is empty.
+type TicketsConfig struct {
+	Repo     string   `key:"repo" env:"TICKET_REPO"`
+	APIURL   string   `key:"api_url" env:"TICKET_API_URL"`
+	APIToken string   `key:"api_token" env:"TICKET_API_TOKEN"`
+	Labels   []string `key:"labels" env:"TICKET_LABELS"`
+	Rules    string   `key:"rules" env:"TICKET_RULES"`
+}
+
+// WebhooksConfig configures outbound webhooks
+type WebhooksConfig struct {
+	AllowHTTP bool `key:"allow_http" env:"WEBHOOK_ALLOW_HTTP"` // for local development only
+}
+
+// Default returns the configuration used when nothing is set
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Environment: EnvDevelopment,
+			Port:        8080,
+			BaseURL:     "http://localhost:8080",
+			CORSOrigins: []string{"*"},
+
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: LogFormatJSON,
+			Redact: true,
+		},
+		Auth: AuthConfig{
+			JWTSecret:         defaultJWTSecret,
+			JWTLifetime:       30 * 24 * time.Hour,
+			MagicLinkTTL:      15 * time.Minute,
+			MagicLinkBurst:    5,
+			MagicLinkInterval: 12 * time.Minute,
+		},
+		Email: EmailConfig{
+			SMTPHost:  "smtp.gmail.com",
+			SMTPPort:  587,
+			FromEmail: "noreply@yourapp.com",
+			FromName:  "Onboarding App",
+		},
+		Passkeys: PasskeyConfig{
+			RPID:   "localhost",
+			RPName: "Onboarding App",
+		},
+		Accounts: AccountsConfig{
+			EmailChangeTTL:          time.Hour,
+			DeletionConfirmationTTL: 10 * time.Minute,
+			DeletionGracePeriod:     30 * 24 * time.Hour,
+		},
+		Feedback: FeedbackConfig{
+			MaxLinks:          2,
+			RateLimitBurst:    5,
+			RateLimitInterval: 2 * time.Minute,
+			DuplicateWindow:   time.Hour,
+			EditWindow:        24 * time.Hour,
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL: 24 * time.Hour,
+		},
+		Analytics: AnalyticsConfig{
+			CacheTTL: 5 * time.Minute,
+		},
+		Attachments: AttachmentsConfig{
+			Dir:          "./data/attachments",
+			LinkTTL:      15 * time.Minute,
+			SlackLinkTTL: 7 * 24 * time.Hour,
+		},
+		Redaction: RedactionConfig{
+			Kinds: []string{"email", "phone", "card", "token"},
+		},
+		Slack: SlackConfig{
+			DefaultChannel: "#feedback",
+			DigestHour:     9,
+
+			FlushDigestOnShutdown: true,
+		},
+		Tickets: TicketsConfig{
+			APIURL: "https://api.github.com",
+			Labels: []string{"feedback"},
+			Rules:  "category=bug",
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the file named by -config or
+// CONFIG_FILE, the environment and the flags in args (without the program name), then
+// validates it
+func Load(args []string) (*Config, error) {
+	var (
+		file      string
+		port      string
+		baseURL   string
+		overrides settingFlags
+	)
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&file, "config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	fs.StringVar(&port, "port", "", "port to listen on (server.port)")
+	fs.StringVar(&baseURL, "base-url", "", "public URL used in email links (server.base_url)")
+	fs.Var(&overrides, "set", "override any setting as section.key=value (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	settings := cfg.settings()
+
+	if file != "" {
+		if err := cfg.loadFile(file, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+
+	if port != "" {
+		overrides = append(overrides, "server.port="+port)
+	}
+	if baseURL != "" {
+		overrides = append(overrides, "server.base_url="+baseURL)
+	}
+	for _, override := range overrides {
+		key, value, _ := strings.Cut(override, "=")
+		s, ok := settings[strings.TrimSpace(key)]
+		if !ok {
+			return nil, fmt.Errorf("-set %s: unknown setting", key)
+		}
+		if err := s.set(value); err != nil {
+			return nil, fmt.Errorf("-set %s: %w", key, err)
+		}
+	}
+
+	cfg.applyDerivedDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyDerivedDefaults fills settings whose default depends on another setting
+func (c *Config) applyDerivedDefaults() {
+	c.Server.BaseURL = strings.TrimRight(c.Server.BaseURL, "/")
+	if len(c.Passkeys.RPOrigins) == 0 {
+		c.Passkeys.RPOrigins = []string{c.Server.BaseURL}
+	}
+	if c.Attachments.SigningKey == "" {
+		c.Attachments.SigningKey = c.Auth.JWTSecret
+	}
+	if c.Slack.DigestChannel == "" {
+		c.Slack.DigestChannel = c.Slack.DefaultChannel
+	}
+	if len(c.Redaction.Kinds) == 1 && strings.EqualFold(c.Redaction.Kinds[0], "none") {
+		c.Redaction.Kinds = nil
+	}
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Environment == EnvDevelopment || c.Server.Environment == EnvStaging || c.Server.Environment == EnvProduction,
+		"server.environment must be development, staging or production")
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port must be between 1 and 65535")
+	check(isHTTPURL(c.Server.BaseURL), "server.base_url must be an absolute http(s) URL")
+	check(len(c.Server.CORSOrigins) > 0, "server.cors_origins must list at least one origin (or *)")
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be positive")
+	check(c.Server.ReadTimeout >= c.Server.ReadHeaderTimeout, "server.read_timeout must be at least server.read_header_timeout")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Logging.Level)) == nil, "logging.level must be debug, info, warn or error")
+	check(c.Logging.Format == LogFormatJSON || c.Logging.Format == LogFormatText, "logging.format must be json or text")
+	check(c.Server.Environment == EnvDevelopment || c.Logging.Redact,
+		"logging.redact can only be turned off in development")
+
+	check(c.Auth.JWTSecret != "", "auth.jwt_secret must be set")
+	check(c.Server.Environment != EnvProduction || c.Auth.JWTSecret != defaultJWTSecret,
+		"auth.jwt_secret must be changed from the default in production")
+	check(c.Auth.JWTLifetime >= time.Minute, "auth.jwt_lifetime must be at least 1m")
+	check(c.Auth.MagicLinkTTL >= time.Minute && c.Auth.MagicLinkTTL <= 24*time.Hour,
+		"auth.magic_link_ttl must be between 1m and 24h")
+	check(c.Auth.MagicLinkBurst >= 1, "auth.magic_link_burst must be at least 1")
+	check(c.Auth.MagicLinkInterval > 0, "auth.magic_link_interval must be positive")
+	check(c.Server.Environment != EnvProduction || !c.Webhooks.AllowHTTP,
+		"webhooks.allow_http must be off in production")
+
+	check(c.Email.SMTPPort > 0 && c.Email.SMTPPort < 65536, "email.smtp_port must be between 1 and 65535")
+	check(strings.Contains(c.Email.FromEmail, "@"), "email.from_email must be an email address")
+
+	check(c.Passkeys.RPID != "", "passkeys.rp_id must be set")
+	for _, origin := range c.Passkeys.RPOrigins {
+		check(isHTTPURL(origin), "passkeys.rp_origins: %q must be an absolute http(s) URL", origin)
+	}
+
+	check(c.Accounts.EmailChangeTTL >= time.Minute && c.Accounts.EmailChangeTTL <= 7*24*time.Hour,
+		"accounts.email_change_ttl must be between 1m and 168h")
+	check(c.Accounts.DeletionConfirmationTTL >= time.Minute && c.Accounts.DeletionConfirmationTTL <= 24*time.Hour,
+		"accounts.deletion_confirmation_ttl must be between 1m and 24h")
+	check(c.Accounts.DeletionGracePeriod >= 0, "accounts.deletion_grace_period must not be negative")
+
+	check(c.Feedback.MaxLinks >= 0, "feedback.max_links must not be negative")
+	check(c.Feedback.RateLimitBurst >= 1, "feedback.rate_limit_burst must be at least 1")
+	check(c.Feedback.RateLimitInterval > 0, "feedback.rate_limit_interval must be positive")
+	check(c.Feedback.DuplicateWindow >= 0, "feedback.duplicate_window must not be negative")
+	check(c.Feedback.EditWindow >= 0, "feedback.edit_window must not be negative")
+	check(c.Idempotency.KeyTTL >= time.Minute, "idempotency.key_ttl must be at least 1m")
+	check(c.Analytics.CacheTTL >= 0, "analytics.cache_ttl must not be negative")
+
+	check(c.Attachments.Dir != "", "attachments.dir must be set")
+	check(c.Attachments.LinkTTL >= time.Minute, "attachments.link_ttl must be at least 1m")
+	check(c.Attachments.SlackLinkTTL >= c.Attachments.LinkTTL, "attachments.slack_link_ttl must be at least attachments.link_ttl")
+
+	check(c.Slack.DefaultChannel != "", "slack.default_channel must be set")
+	check(c.Slack.DigestHour >= 0 && c.Slack.DigestHour <= 23, "slack.digest_hour must be between 0 and 23")
+
+	if c.Tickets.Repo != "" {
+		owner, name, ok := strings.Cut(c.Tickets.Repo, "/")
+		check(ok && owner != "" && name != "" && !strings.Contains(name, "/"), "tickets.repo must be owner/name")
+		check(isHTTPURL(c.Tickets.APIURL), "tickets.api_url must be an absolute http(s) URL")
+	}
+
+	return errors.Join(errs...)
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// setting is one configurable field
+type setting struct {
+	env   string
+	value reflect.Value
+}
+
+// set parses raw into the field. Lists are comma-separated.
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(raw)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", raw)
+		}
+		s.value.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		s.value.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 15m or 24h", raw)
+		}
+		s.value.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// settings returns every field keyed by "section.key"
+func (c *Config) settings() map[string]setting {
+	result := make(map[string]setting)
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i).Tag.Get("key")
+		fields := root.Field(i)
+		for j := 0; j < fields.NumField(); j++ {
+			field := fields.Type().Field(j)
+			result[section+"."+field.Tag.Get("key")] = setting{env: field.Tag.Get("env"), value: fields.Field(j)}
+		}
+	}
+	return result
+}
+
+// loadFile applies the settings in a YAML (.yaml, .yml) or TOML (.toml) file
+func (c *Config) loadFile(path string, settings map[string]setting) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	var sections map[string]map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &sections)
+	case ".toml":
+		err = toml.Unmarshal(data, &sections)
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	for section, values := range sections {
+		for key, value := range values {
+			name := section + "." + key
+			s, ok := settings[name]
+			if !ok {
+				return fmt.Errorf("config file %s: unknown setting %s", path, name)
+			}
+			if err := s.set(fileValue(value)); err != nil {
+				return fmt.Errorf("config file %s: %s: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fileValue converts a decoded file value to the string form settings parse. Lists
+// are joined with commas.
+func fileValue(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// settingFlags collects repeated -set flags
+type settingFlags []string
+
+func (f *settingFlags) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *settingFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return errors.New("must be section.key=value")
+	}
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	cfg := Default()
+	cfg.applyDerivedDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+}
+
+func TestLoadReadsLimitsAndTTLs(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("FEEDBACK_RATE_LIMIT_BURST", "10")
+	t.Setenv("FEEDBACK_EDIT_WINDOW", "1h")
+	t.Setenv("IDEMPOTENCY_KEY_TTL", "2h")
+	t.Setenv("ANALYTICS_CACHE_TTL", "0s")
+
+	cfg, err := Load([]string{
+		"-set", "feedback.rate_limit_interval=30s",
+		"-set", "accounts.deletion_grace_period=48h",
+		"-set", "attachments.link_ttl=5m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Feedback.RateLimitBurst != 10 || cfg.Feedback.RateLimitInterval != 30*time.Second {
+		t.Errorf("feedback rate limit = %d every %s, want 10 every 30s", cfg.Feedback.RateLimitBurst, cfg.Feedback.RateLimitInterval)
+	}
+	if cfg.Feedback.EditWindow != time.Hour {
+		t.Errorf("feedback.edit_window = %s, want 1h", cfg.Feedback.EditWindow)
+	}
+	if cfg.Idempotency.KeyTTL != 2*time.Hour {
+		t.Errorf("idempotency.key_ttl = %s, want 2h", cfg.Idempotency.KeyTTL)
+	}
+	if cfg.Analytics.CacheTTL != 0 {
+		t.Errorf("analytics.cache_ttl = %s, want 0s", cfg.Analytics.CacheTTL)
+	}
+	if cfg.Accounts.DeletionGracePeriod != 48*time.Hour {
+		t.Errorf("accounts.deletion_grace_period = %s, want 48h", cfg.Accounts.DeletionGracePeriod)
+	}
+	if cfg.Attachments.LinkTTL != 5*time.Minute {
+		t.Errorf("attachments.link_ttl = %s, want 5m", cfg.Attachments.LinkTTL)
+	}
+	if cfg.Accounts.EmailChangeTTL != time.Hour || cfg.Feedback.DuplicateWindow != time.Hour {
+		t.Errorf("unset settings changed from their defaults")
+	}
+}
+
+func TestValidateRejectsBadLimitsAndTTLs(t *testing.T) {
+	cfg := Default()
+	cfg.applyDerivedDefaults()
+	cfg.Feedback.RateLimitBurst = 0
+	cfg.Feedback.RateLimitInterval = 0
+	cfg.Feedback.EditWindow = -time.Hour
+	cfg.Accounts.DeletionConfirmationTTL = 0
+	cfg.Idempotency.KeyTTL = time.Second
+	cfg.Attachments.SlackLinkTTL = time.Minute
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted invalid limits")
+	}
+	for _, key := range []string{
+		"feedback.rate_limit_burst",
+		"feedback.rate_limit_interval",
+		"feedback.edit_window",
+		"accounts.deletion_confirmation_ttl",
+		"idempotency.key_ttl",
+		"attachments.slack_link_ttl",
+	} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error does not mention %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package logging builds the application's structured logger.
+//
+// Records are written as JSON by default. Attributes whose key names a secret (tokens,
+// passwords, signatures, links carrying tokens) are replaced with [redacted], and personal
+// data in the remaining text is removed with the configured redactor. Records logged
+// with a request's context carry its request_id.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"onboarding-backend/internal/config"
+)
+
+// RedactedValue replaces the value of secret attributes
+const RedactedValue = "[redacted]"
+
+// secretKeyParts mark attribute keys whose values are never logged
+var secretKeyParts = []string{"token", "password", "secret", "authorization", "cookie", "signature"}
+
+// Redactor removes personal data from text
+type Redactor interface {
+	Redact(text string) string
+}
+
+// New creates a logger writing to w at the configured level and format. Unless
+// cfg.Redact is off, secrets and personal data are removed with redactor.
+func New(w io.Writer, cfg config.LoggingConfig, redactor Redactor) *slog.Logger {
+	var level slog.Level
+	// Validated by config, so this can't fail
+	_ = level.UnmarshalText([]byte(cfg.Level))
+
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Redact {
+		opts.ReplaceAttr = func(_ []string, a slog.Attr) slog.Attr {
+			return redactAttr(a, redactor)
+		}
+	}
+
+	var handler slog.Handler
+	if cfg.Format == config.LogFormatText {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// isSecretKey reports whether an attribute key names a secret. Links such as magic
+// links carry tokens, so they count too.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "link" || strings.HasSuffix(key, "_link") {
+		return true
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr hides secret attributes and removes personal data from text values,
+// including the message and errors
+func redactAttr(a slog.Attr, redactor Redactor) slog.Attr {
+	if isSecretKey(a.Key) {
+		return slog.String(a.Key, RedactedValue)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactor.Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, redactor.Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request ID from the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		r.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
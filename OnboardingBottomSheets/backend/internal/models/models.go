@@ -1,13 +1,45 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// User roles, carried in the JWT "role" claim
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
 
 // User represents a user in the system
 type User struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
+	ID                    string     `json:"id"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	DisplayName           string     `json:"display_name"`
+	Locale                string     `json:"locale"`
+	Timezone              string     `json:"timezone"`
+	MarketingConsent      bool       `json:"marketing_consent"`
+	MarketingConsentAt    *time.Time `json:"marketing_consent_at,omitempty"`
+	PendingEmail          string     `json:"pending_email,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+	DeletionScheduledFor  *time.Time `json:"deletion_scheduled_for,omitempty"`
+	FirstLoginAt          *time.Time `json:"first_login_at,omitempty"`
+	LastLoginAt           *time.Time `json:"last_login_at,omitempty"`
+	LoginCount            int        `json:"login_count"`
+	OnboardingCompletedAt *time.Time `json:"onboarding_completed_at,omitempty"`
+	IsNewUser             bool       `json:"is_new_user"` // true until onboarding is completed
+}
+
+// EmailChange represents a pending change of a user's email address
+type EmailChange struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"user_id"`
+	NewEmail  string    `json:"new_email"`
+	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
-	IsNewUser bool      `json:"is_new_user"`
 }
 
 // MagicLink represents a magic link for authentication
@@ -19,14 +51,165 @@ type MagicLink struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Session represents a login session backing an issued JWT
+type Session struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Method    string     `json:"method"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// PasskeyCredential represents a WebAuthn credential registered by a user
+type PasskeyCredential struct {
+	ID              string    `json:"id"` // base64url-encoded credential ID
+	UserID          string    `json:"user_id"`
+	PublicKey       []byte    `json:"-"`
+	AttestationType string    `json:"attestation_type"`
+	Transports      []string  `json:"transports"`
+	AAGUID          []byte    `json:"-"`
+	SignCount       uint32    `json:"sign_count"`
+	BackupEligible  bool      `json:"backup_eligible"`
+	BackupState     bool      `json:"backup_state"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastUsedAt      time.Time `json:"last_used_at"`
+}
+
+// Feedback categories
+const (
+	FeedbackCategoryBug    = "bug"
+	FeedbackCategoryIdea   = "idea"
+	FeedbackCategoryPraise = "praise"
+)
+
+// Feedback platforms. Submissions without a platform are recorded as unknown.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWeb     = "web"
+	PlatformUnknown = "unknown"
+)
+
+// Feedback statuses
+const (
+	FeedbackStatusNew        = "new"
+	FeedbackStatusTriaged    = "triaged"
+	FeedbackStatusInProgress = "in_progress"
+	FeedbackStatusResolved   = "resolved"
+	FeedbackStatusWontFix    = "wont_fix"
+)
+
+// Feedback moderation states
+const (
+	ModerationApproved = "approved"
+	ModerationHeld     = "held"
+	ModerationRejected = "rejected"
+)
+
 // Feedback represents user feedback
 type Feedback struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Email     string    `json:"email"`
-	Content   string    `json:"content"`
-	Platform  string    `json:"platform"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            string                  `json:"id"`
+	UserID        string                  `json:"user_id"`
+	Email         string                  `json:"email"`
+	Content       string                  `json:"content"`
+	Platform      string                  `json:"platform"`
+	Rating        int                     `json:"rating,omitempty"`   // 1-5 stars, 0 when not given
+	Category      string                  `json:"category,omitempty"` // bug, idea or praise
+	Screen        string                  `json:"screen,omitempty"`   // screen the user came from
+	Tags          []string                `json:"tags,omitempty"`
+	Attachments   []*Attachment           `json:"attachments,omitempty"`
+	Status        string                  `json:"status"`
+	StatusHistory []*FeedbackStatusChange `json:"status_history,omitempty"`
+	Replies       []*FeedbackReply        `json:"replies,omitempty"`
+	// Held feedback is not published until a reviewer approves it
+	ModerationStatus  string     `json:"moderation_status"`
+	ModerationReasons []string   `json:"moderation_reasons,omitempty"`
+	TicketURL         string     `json:"ticket_url,omitempty"` // issue created in the tracker
+	CreatedAt         time.Time  `json:"created_at"`
+	EditedAt          *time.Time `json:"edited_at,omitempty"`
+	PublishedAt       *time.Time `json:"published_at,omitempty"` // first approved and published
+}
+
+// FeedbackStatusChange records one status transition of a feedback entry
+type FeedbackStatusChange struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedBy string    `json:"changed_by"` // user ID
+	Note      string    `json:"note,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// FeedbackReply is a message in the conversation thread of a feedback entry
+type FeedbackReply struct {
+	ID         string    `json:"id"`
+	FeedbackID string    `json:"feedback_id"`
+	ParentID   string    `json:"parent_id,omitempty"` // reply being answered, empty for top level
+	AuthorID   string    `json:"author_id"`
+	AuthorRole string    `json:"author_role"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Attachment represents a file (screenshot or log) uploaded for a feedback entry
+type Attachment struct {
+	ID          string    `json:"id"`
+	FeedbackID  string    `json:"feedback_id"`
+	UserID      string    `json:"user_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription is an endpoint that receives events as signed HTTP POSTs
+type WebhookSubscription struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"` // event types, or "*" for all
+	Description string    `json:"description,omitempty"`
+	Secret      string    `json:"secret,omitempty"` // only returned when the subscription is created
+	CreatedBy   string    `json:"created_by"`       // user ID
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookEvent is the JSON body posted to subscribers
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery records the attempts to deliver one event to one subscription
+type WebhookDelivery struct {
+	ID             string            `json:"id"`
+	SubscriptionID string            `json:"subscription_id"`
+	EventID        string            `json:"event_id"`
+	EventType      string            `json:"event_type"`
+	Payload        json.RawMessage   `json:"payload"` // event body as sent
+	Status         string            `json:"status"`
+	Attempts       []*WebhookAttempt `json:"attempts"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty"`
+	RedeliveryOf   string            `json:"redelivery_of,omitempty"` // delivery ID
+	UserID         string            `json:"-"`                       // user the event is about, purged with their account
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// WebhookAttempt is one HTTP request made for a delivery
+type WebhookAttempt struct {
+	StatusCode  int       `json:"status_code,omitempty"` // 0 when no response was received
+	Error       string    `json:"error,omitempty"`
+	Response    string    `json:"response,omitempty"` // start of the response body
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
 }
 
 // AuthResponse represents the authentication response
@@ -42,8 +225,121 @@ type RequestMagicLinkRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+// UpdateFeedbackRequest represents the request body for editing feedback.
+// Nil fields are left unchanged.
+type UpdateFeedbackRequest struct {
+	Content  *string   `json:"content"`
+	Rating   *int      `json:"rating" binding:"omitempty,min=1,max=5"`
+	Category *string   `json:"category" binding:"omitempty,oneof=bug idea praise"`
+	Screen   *string   `json:"screen" binding:"omitempty,max=100"`
+	Tags     *[]string `json:"tags" binding:"omitempty,max=10,dive,min=1,max=32"`
+}
+
+// UpdateFeedbackStatusRequest represents the request body for a status change
+type UpdateFeedbackStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=new triaged in_progress resolved wont_fix"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+// ModerateFeedbackRequest represents a reviewer's decision on held feedback
+type ModerateFeedbackRequest struct {
+	Action string `json:"action" binding:"required,oneof=approve reject"`
+}
+
+// FeedbackReplyRequest represents the request body for replying to feedback
+type FeedbackReplyRequest struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID string `json:"parent_id"`
+}
+
+// UpdateProfileRequest represents the request body for a profile update.
+// Nil fields are left unchanged.
+type UpdateProfileRequest struct {
+	DisplayName      *string `json:"display_name" binding:"omitempty,max=100"`
+	Locale           *string `json:"locale"`
+	Timezone         *string `json:"timezone"`
+	MarketingConsent *bool   `json:"marketing_consent"`
+}
+
+// ChangeEmailRequest represents the request body for starting an email change
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// DeleteAccountRequest represents the request body for account deletion.
+// An empty confirmation token starts the confirmation step.
+type DeleteAccountRequest struct {
+	ConfirmationToken string `json:"confirmation_token"`
+}
+
+// AccountExport is the archive of a user's data returned by the data export
+type AccountExport struct {
+	ExportedAt time.Time            `json:"exported_at"`
+	Profile    *User                `json:"profile"`
+	Sessions   []*Session           `json:"sessions"`
+	Passkeys   []*PasskeyCredential `json:"passkeys"`
+	Feedback   []*Feedback          `json:"feedback"`
+}
+
+// FeedbackAnalytics aggregates feedback over a time window
+type FeedbackAnalytics struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	TotalSubmissions int            `json:"total_submissions"`
+	PerDay           []DailyCount   `json:"per_day"`
+	PerPlatform      map[string]int `json:"per_platform"`
+
+	// AverageRating is nil when no feedback in the window was rated
+	AverageRating    *float64 `json:"average_rating"`
+	RatedSubmissions int      `json:"rated_submissions"`
+
+	// NewUserFeedbackShare is the fraction of users who signed up in the window and
+	// submitted feedback in it, nil when nobody signed up
+	NewUsers             int      `json:"new_users"`
+	NewUsersWithFeedback int      `json:"new_users_with_feedback"`
+	NewUserFeedbackShare *float64 `json:"new_user_feedback_share"`
+
+	TopKeywords []KeywordCount `json:"top_keywords"`
+}
+
+// DailyCount is the number of submissions on one UTC day
+type DailyCount struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int    `json:"count"`
+}
+
+// KeywordCount is how many feedback entries mention a keyword
+type KeywordCount struct {
+	Keyword string `json:"keyword"`
+	Count   int    `json:"count"`
+}
+
+// UpdateRoleRequest represents the request body for changing a user's role
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user support admin"`
+}
+
+// CreateWebhookRequest represents the request body for registering a webhook endpoint
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url"`
+	Events      []string `json:"events" binding:"required,min=1,dive,required"`
+	Description string   `json:"description" binding:"omitempty,max=200"`
+}
+
+// BeginPasskeyLoginRequest represents the request body for starting a passkey login.
+// Email is optional; without it the client performs a discoverable credential login.
+type BeginPasskeyLoginRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
 // SubmitFeedbackRequest represents the request body for feedback submission
 type SubmitFeedbackRequest struct {
-	Content  string `json:"content" binding:"required"`
-	Platform string `json:"platform"`
+	Content  string   `json:"content" binding:"required"`
+	Platform string   `json:"platform"`
+	Rating   int      `json:"rating" binding:"omitempty,min=1,max=5"`
+	Category string   `json:"category" binding:"omitempty,oneof=bug idea praise"`
+	Screen   string   `json:"screen" binding:"omitempty,max=100"`
+	Tags     []string `json:"tags" binding:"omitempty,max=10,dive,min=1,max=32"`
 }
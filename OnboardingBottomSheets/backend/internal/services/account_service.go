@@ -0,0 +1,253 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+var (
+	ErrInvalidConfirmation = errors.New("invalid or expired confirmation token")
+)
+
+// deletionConfirmation is a pending confirmation of an account deletion request
+type deletionConfirmation struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// AccountService handles account deletion and data export across all services
+type AccountService struct {
+	userService       *UserService
+	authService       *AuthService
+	passkeyService    *PasskeyService
+	feedbackService   *FeedbackService
+	attachmentService *AttachmentService
+	webhookService    *WebhookService
+	slackService      SlackService
+	emailService      *EmailService
+	idempotency       *IdempotencyService
+	confirmationTTL   time.Duration
+	gracePeriod       time.Duration
+	confirmations     map[string]*deletionConfirmation // token -> confirmation
+	logger            *slog.Logger
+	mu                sync.Mutex
+}
+
+// NewAccountService creates a new account service using the configured confirmation
+// expiry and deletion grace period
+func NewAccountService(
+	userService *UserService,
+	authService *AuthService,
+	passkeyService *PasskeyService,
+	feedbackService *FeedbackService,
+	attachmentService *AttachmentService,
+	webhookService *WebhookService,
+	slackService SlackService,
+	emailService *EmailService,
+	idempotency *IdempotencyService,
+	cfg config.AccountsConfig,
+	logger *slog.Logger,
+) *AccountService {
+	return &AccountService{
+		userService:       userService,
+		authService:       authService,
+		passkeyService:    passkeyService,
+		feedbackService:   feedbackService,
+		attachmentService: attachmentService,
+		webhookService:    webhookService,
+		slackService:      slackService,
+		emailService:      emailService,
+		idempotency:       idempotency,
+		confirmationTTL:   cfg.DeletionConfirmationTTL,
+		gracePeriod:       cfg.DeletionGracePeriod,
+		confirmations:     make(map[string]*deletionConfirmation),
+		logger:            logger,
+	}
+}
+
+// RequestDeletion starts the confirmation step of an account deletion and
+// returns the token that must be sent back to confirm it
+func (s *AccountService) RequestDeletion(userID string) (string, time.Time, error) {
+	if _, exists := s.userService.GetUserByID(userID); !exists {
+		return "", time.Time{}, ErrUserNotFound
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(tokenBytes)
+	expiresAt := time.Now().Add(s.confirmationTTL)
+
+	s.mu.Lock()
+	s.confirmations[token] = &deletionConfirmation{userID: userID, expiresAt: expiresAt}
+	s.mu.Unlock()
+
+	return token, expiresAt, nil
+}
+
+// ConfirmDeletion schedules the account for deletion after the grace period and
+// signs the user out everywhere. Signing in again before then cancels the deletion.
+func (s *AccountService) ConfirmDeletion(ctx context.Context, userID, token string) (time.Time, error) {
+	s.mu.Lock()
+	confirmation, exists := s.confirmations[token]
+	if exists {
+		delete(s.confirmations, token)
+	}
+	s.mu.Unlock()
+
+	if !exists || confirmation.userID != userID || time.Now().After(confirmation.expiresAt) {
+		return time.Time{}, ErrInvalidConfirmation
+	}
+
+	user, exists := s.userService.GetUserByID(userID)
+	if !exists {
+		return time.Time{}, ErrUserNotFound
+	}
+
+	deleteAt := time.Now().Add(s.gracePeriod)
+	if err := s.userService.ScheduleDeletion(userID, deleteAt); err != nil {
+		return time.Time{}, err
+	}
+	s.authService.RevokeUserSessions(userID)
+
+	if err := s.emailService.SendAccountDeletionScheduled(ctx, user.Email, deleteAt); err != nil {
+		s.logger.WarnContext(ctx, "failed to send deletion notice", "user_id", userID, "to", user.Email, "error", err)
+	}
+
+	return deleteAt, nil
+}
+
+// DeleteAccount permanently removes a user and everything tied to them
+func (s *AccountService) DeleteAccount(ctx context.Context, userID string) error {
+	user, err := s.userService.DeleteUser(userID)
+	if err != nil {
+		return err
+	}
+
+	var feedbackIDs []string
+	for _, fb := range s.feedbackService.GetFeedbackByUser(user.ID) {
+		feedbackIDs = append(feedbackIDs, fb.ID)
+	}
+
+	s.authService.DeleteUserData(user.ID, user.Email)
+	s.passkeyService.DeleteCredentials(user.ID)
+	s.attachmentService.DeleteByUser(ctx, user.ID)
+	s.feedbackService.DeleteFeedbackByUser(user.ID)
+	s.feedbackService.DeleteModerationState(user.ID)
+	// Stored responses replay feedback and attachment bodies
+	s.idempotency.DeleteByUser(user.ID)
+	// Logged webhook payloads and the Slack digest queue hold copies of the user's data
+	s.webhookService.DeleteByUser(user.ID)
+	s.slackService.DeleteFromDigest(feedbackIDs)
+
+	s.mu.Lock()
+	for token, confirmation := range s.confirmations {
+		if confirmation.userID == userID {
+			delete(s.confirmations, token)
+		}
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// PurgeDueDeletions deletes every account whose grace period has ended
+func (s *AccountService) PurgeDueDeletions(ctx context.Context) int {
+	purged := 0
+	for _, userID := range s.userService.GetUsersDueForDeletion(time.Now()) {
+		if err := s.DeleteAccount(ctx, userID); err != nil {
+			s.logger.ErrorContext(ctx, "failed to delete account", "user_id", userID, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+// StartPurger runs PurgeDueDeletions on the given interval until ctx ends
+func (s *AccountService) StartPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if purged := s.PurgeDueDeletions(ctx); purged > 0 {
+					s.logger.InfoContext(ctx, "deleted accounts after their grace period", "count", purged)
+				}
+			}
+		}
+	}()
+}
+
+// ExportData collects everything stored about a user
+func (s *AccountService) ExportData(userID string) (*models.AccountExport, error) {
+	profile, err := s.userService.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    profile,
+		Sessions:   s.authService.GetSessionsByUser(userID),
+		Passkeys:   s.passkeyService.GetCredentials(userID),
+		Feedback:   s.feedbackService.GetFeedbackByUser(userID),
+	}
+
+	// Keep empty sections as [] rather than null in the archive
+	if export.Sessions == nil {
+		export.Sessions = []*models.Session{}
+	}
+	if export.Feedback == nil {
+		export.Feedback = []*models.Feedback{}
+	}
+	return export, nil
+}
+
+// WriteExportZip writes the export as a ZIP archive with one JSON file per section
+func WriteExportZip(w io.Writer, export *models.AccountExport) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"sessions.json", export.Sessions},
+		{"passkeys.json", export.Passkeys},
+		{"feedback.json", export.Feedback},
+		{"export.json", export},
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+
+	"golang.org/x/time/rate"
+)
+
+// testAccounts wires an account service to every service it deletes from
+type testAccounts struct {
+	accounts    *AccountService
+	users       *UserService
+	auth        *AuthService
+	feedback    *FeedbackService
+	webhooks    *WebhookService
+	background  *Background
+	slack       *MockSlackService
+	idempotency *IdempotencyService
+	rateLimit   *RateLimitCheck
+}
+
+// newTestAccounts allows one magic link and one feedback submission per user, and
+// routes praise to the Slack digest
+func newTestAccounts(t *testing.T) *testAccounts {
+	t.Helper()
+	logger := newTestLogger()
+	emailService, userService := newTestUsers(logger, config.RolesConfig{})
+	authService := NewAuthService(emailService, userService, config.AuthConfig{
+		JWTSecret:         "test-secret",
+		MagicLinkTTL:      config.Default().Auth.MagicLinkTTL,
+		MagicLinkBurst:    1,
+		MagicLinkInterval: config.Default().Auth.MagicLinkInterval,
+	}, testBaseURL, logger)
+	passkeyService, err := NewPasskeyService(authService, config.PasskeyConfig{RPID: "localhost", RPName: "Test", RPOrigins: []string{testBaseURL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rateLimit := NewRateLimitCheck(rate.Every(time.Hour), 1)
+	feedbackService := NewFeedbackService(userService, emailService, nopEvents{}, config.Default().Feedback, logger, rateLimit)
+	store, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachmentService := NewAttachmentService(store, feedbackService, config.AttachmentsConfig{SigningKey: "key"}, testBaseURL, logger)
+	background := NewBackground()
+	webhookService := NewWebhookService(newTestRedactor(t), config.WebhooksConfig{AllowHTTP: true}, background, logger)
+	routing, err := SlackRoutingFromConfig(config.SlackConfig{Routes: "category=praise => digest", DefaultChannel: "#feedback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slack := NewMockSlackService(newTestRedactor(t), routing, feedbackService.GetFeedback, logger)
+	idempotency := NewIdempotencyService(config.Default().Idempotency)
+
+	return &testAccounts{
+		accounts:    NewAccountService(userService, authService, passkeyService, feedbackService, attachmentService, webhookService, slack, emailService, idempotency, config.Default().Accounts, logger),
+		users:       userService,
+		auth:        authService,
+		feedback:    feedbackService,
+		webhooks:    webhookService,
+		background:  background,
+		slack:       slack,
+		idempotency: idempotency,
+		rateLimit:   rateLimit,
+	}
+}
+
+func TestDeleteAccountPurgesIdempotencyAndRateLimitState(t *testing.T) {
+	env := newTestAccounts(t)
+
+	user := env.users.GetOrCreateUser("user@example.com")
+	other := env.users.GetOrCreateUser("other@example.com")
+
+	for _, u := range []*models.User{user, other} {
+		if _, err := env.idempotency.Begin(u.ID, u.ID+"/key", "hash"); err != nil {
+			t.Fatal(err)
+		}
+		env.idempotency.Complete(u.ID+"/key", &IdempotentResponse{Status: 201, Body: []byte(u.Email)})
+		if result := env.rateLimit.Check(&models.Feedback{UserID: u.ID}, nil); result.Action != ModerationPass {
+			t.Fatalf("first submission by %s was not allowed", u.Email)
+		}
+	}
+	if _, err := env.auth.GenerateMagicLink(context.Background(), "USER@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.accounts.DeleteAccount(context.Background(), user.ID); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+
+	if stored, _ := env.idempotency.Begin(user.ID, user.ID+"/key", "hash"); stored != nil {
+		t.Error("stored response of the deleted user is still replayed")
+	}
+	if stored, _ := env.idempotency.Begin(other.ID, other.ID+"/key", "hash"); stored == nil {
+		t.Error("stored response of another user was removed")
+	}
+	if result := env.rateLimit.Check(&models.Feedback{UserID: user.ID}, nil); result.Action != ModerationPass {
+		t.Error("feedback rate limit of the deleted user was kept")
+	}
+	if result := env.rateLimit.Check(&models.Feedback{UserID: other.ID}, nil); result.Action != ModerationReject {
+		t.Error("feedback rate limit of another user was reset")
+	}
+	if _, err := env.auth.GenerateMagicLink(context.Background(), "user@example.com"); err != nil {
+		t.Errorf("magic link rate limit of the deleted user was kept: %v", err)
+	}
+}
+
+func TestDeleteAccountPurgesWebhookDeliveriesAndDigestEntries(t *testing.T) {
+	env := newTestAccounts(t)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+	sub, err := env.webhooks.CreateSubscription("admin-1", models.CreateWebhookRequest{URL: receiver.URL, Events: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := env.users.GetOrCreateUser("user@example.com")
+	other := env.users.GetOrCreateUser("other@example.com")
+	for _, u := range []*models.User{user, other} {
+		fb, err := env.feedback.StoreFeedback(u.ID, u.Email, models.SubmitFeedbackRequest{Content: "Lovely app", Platform: "ios", Category: "praise"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.webhooks.Publish(EventUserCreated, u)
+		env.webhooks.Publish(EventFeedbackCreated, fb)
+		if err := env.slack.PublishFeedback(context.Background(), fb); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := env.background.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.accounts.DeleteAccount(context.Background(), user.ID); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+
+	deliveries, err := env.webhooks.ListDeliveries(sub.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Errorf("%d deliveries logged after deleting the account, want the other user's 2", len(deliveries))
+	}
+	for _, delivery := range deliveries {
+		if delivery.UserID != other.ID {
+			t.Errorf("delivery %s of a %s event about %s was kept", delivery.ID, delivery.EventType, delivery.UserID)
+		}
+	}
+	if pending := env.slack.PendingDigest(); pending != 1 {
+		t.Errorf("%d entries waiting for the digest, want the other user's 1", pending)
+	}
+}
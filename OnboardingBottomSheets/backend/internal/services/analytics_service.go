@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+var (
+	ErrInvalidAnalyticsWindow = errors.New("invalid analytics window")
+)
+
+const (
+	// MaxAnalyticsWindow caps how long a window analytics can be computed over
+	MaxAnalyticsWindow = 366 * 24 * time.Hour
+
+	// topKeywordCount is how many keywords are reported
+	topKeywordCount = 10
+
+	// minKeywordLength skips very short words when counting keywords
+	minKeywordLength = 3
+)
+
+// cachedAnalytics is a computed result and when it stops being reused
+type cachedAnalytics struct {
+	result    *models.FeedbackAnalytics
+	expiresAt time.Time
+}
+
+// AnalyticsService computes and caches aggregates over feedback
+type AnalyticsService struct {
+	feedbackService *FeedbackService
+	userService     *UserService
+	cache           map[string]*cachedAnalytics // window -> result
+	ttl             time.Duration
+	mu              sync.Mutex
+}
+
+// NewAnalyticsService creates a new analytics service that reuses results for the
+// configured cache TTL
+func NewAnalyticsService(feedbackService *FeedbackService, userService *UserService, cfg config.AnalyticsConfig) *AnalyticsService {
+	return &AnalyticsService{
+		feedbackService: feedbackService,
+		userService:     userService,
+		cache:           make(map[string]*cachedAnalytics),
+		ttl:             cfg.CacheTTL,
+	}
+}
+
+// FeedbackAnalytics returns aggregates over feedback created in [from, to). Results
+// are cached per window for the configured TTL.
+func (s *AnalyticsService) FeedbackAnalytics(from, to time.Time) (*models.FeedbackAnalytics, error) {
+	if !from.Before(to) || to.Sub(from) > MaxAnalyticsWindow {
+		return nil, ErrInvalidAnalyticsWindow
+	}
+	from, to = from.UTC(), to.UTC()
+	key := from.Format(time.RFC3339Nano) + "/" + to.Format(time.RFC3339Nano)
+	now := time.Now()
+
+	s.mu.Lock()
+	if cached, exists := s.cache[key]; exists && now.Before(cached.expiresAt) {
+		s.mu.Unlock()
+		return cached.result, nil
+	}
+	s.mu.Unlock()
+
+	result := s.compute(from, to)
+
+	s.mu.Lock()
+	for k, cached := range s.cache {
+		if !now.Before(cached.expiresAt) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = &cachedAnalytics{result: result, expiresAt: now.Add(s.ttl)}
+	s.mu.Unlock()
+
+	return result, nil
+}
+
+// compute aggregates the feedback in the window. Rejected spam is left out.
+func (s *AnalyticsService) compute(from, to time.Time) *models.FeedbackAnalytics {
+	result := &models.FeedbackAnalytics{
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+		PerPlatform: make(map[string]int),
+		TopKeywords: []models.KeywordCount{},
+	}
+
+	// One bucket per UTC day touched by the window
+	dayIndex := make(map[string]int)
+	for day := from.Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		dayIndex[date] = len(result.PerDay)
+		result.PerDay = append(result.PerDay, models.DailyCount{Date: date})
+	}
+
+	submitters := make(map[string]bool)
+	keywords := newKeywordCounter()
+	ratingSum := 0
+
+	for _, fb := range s.feedbackService.GetFeedbackBetween(from, to) {
+		if fb.ModerationStatus == models.ModerationRejected {
+			continue
+		}
+
+		result.TotalSubmissions++
+		result.PerDay[dayIndex[fb.CreatedAt.UTC().Format("2006-01-02")]].Count++
+		result.PerPlatform[fb.Platform]++
+		if fb.Rating > 0 {
+			result.RatedSubmissions++
+			ratingSum += fb.Rating
+		}
+		submitters[fb.UserID] = true
+		keywords.add(fb.Content)
+	}
+
+	if result.RatedSubmissions > 0 {
+		average := float64(ratingSum) / float64(result.RatedSubmissions)
+		result.AverageRating = &average
+	}
+
+	for _, userID := range s.userService.GetUsersCreatedBetween(from, to) {
+		result.NewUsers++
+		if submitters[userID] {
+			result.NewUsersWithFeedback++
+		}
+	}
+	if result.NewUsers > 0 {
+		share := float64(result.NewUsersWithFeedback) / float64(result.NewUsers)
+		result.NewUserFeedbackShare = &share
+	}
+
+	result.TopKeywords = keywords.top(topKeywordCount)
+	return result
+}
+
+// keywordCounter counts how many entries mention each stemmed keyword, remembering
+// the most common way it was written
+type keywordCounter struct {
+	entries map[string]int            // stem -> entries mentioning it
+	forms   map[string]map[string]int // stem -> surface form -> occurrences
+}
+
+func newKeywordCounter() *keywordCounter {
+	return &keywordCounter{
+		entries: make(map[string]int),
+		forms:   make(map[string]map[string]int),
+	}
+}
+
+// add counts the keywords in one feedback entry
+func (k *keywordCounter) add(content string) {
+	seen := make(map[string]bool)
+	for _, token := range tokenize(content) {
+		word := strings.ToLower(content[token.start:token.end])
+		if stopWords[word] || utf8.RuneCountInString(word) < minKeywordLength {
+			continue
+		}
+		if k.forms[token.term] == nil {
+			k.forms[token.term] = make(map[string]int)
+		}
+		k.forms[token.term][word]++
+		if !seen[token.term] {
+			seen[token.term] = true
+			k.entries[token.term]++
+		}
+	}
+}
+
+// top returns the n keywords mentioned by the most entries
+func (k *keywordCounter) top(n int) []models.KeywordCount {
+	result := make([]models.KeywordCount, 0, len(k.entries))
+	for term, count := range k.entries {
+		result = append(result, models.KeywordCount{Keyword: k.commonForm(term), Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Keyword < result[j].Keyword
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// commonForm returns the most frequent surface form of a stem
+func (k *keywordCounter) commonForm(term string) string {
+	best, bestCount := term, 0
+	for form, count := range k.forms[term] {
+		if count > bestCount || (count == bestCount && form < best) {
+			best, bestCount = form, count
+		}
+	}
+	return best
+}
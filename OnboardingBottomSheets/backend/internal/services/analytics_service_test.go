@@ -0,0 +1,231 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+// analyticsDay returns midnight UTC on the given day of March 2024
+func analyticsDay(day int) time.Time {
+	return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+}
+
+// newTestAnalytics returns an analytics service over an empty feedback service
+func newTestAnalytics(ttl time.Duration) (*AnalyticsService, *FeedbackService) {
+	feedback, _ := newTestFeedbackService()
+	return NewAnalyticsService(feedback, feedback.userService, config.AnalyticsConfig{CacheTTL: ttl}), feedback
+}
+
+// addUserCreatedAt signs up a user and backdates their account
+func addUserCreatedAt(users *UserService, email string, createdAt time.Time) string {
+	user := users.GetOrCreateUser(email)
+	users.mu.Lock()
+	users.users[user.ID].CreatedAt = createdAt
+	users.mu.Unlock()
+	return user.ID
+}
+
+func TestFeedbackAnalyticsAggregates(t *testing.T) {
+	analytics, feedback := newTestAnalytics(0)
+	users := feedback.userService
+
+	newUser := addUserCreatedAt(users, "new@example.com", analyticsDay(1).Add(8*time.Hour))
+	oldUser := addUserCreatedAt(users, "old@example.com", analyticsDay(1).AddDate(0, -1, 0))
+	addUserCreatedAt(users, "quiet@example.com", analyticsDay(2).Add(10*time.Hour))
+	seedFeedback(feedback,
+		&models.Feedback{UserID: newUser, Platform: "ios", Rating: 4, Content: "The app crashes on launch", CreatedAt: analyticsDay(1).Add(9 * time.Hour)},
+		&models.Feedback{UserID: oldUser, Platform: "android", Rating: 2, Content: "Crashing after the update", CreatedAt: analyticsDay(2).Add(-time.Nanosecond)},
+		&models.Feedback{UserID: oldUser, Platform: "ios", Content: "Love the dark mode", CreatedAt: analyticsDay(2)},
+		&models.Feedback{UserID: newUser, Platform: "web", Rating: 5, Content: "Dark mode please", CreatedAt: analyticsDay(3).Add(12 * time.Hour)},
+		&models.Feedback{UserID: oldUser, Platform: "ios", Rating: 1, Content: "spam spam", CreatedAt: analyticsDay(3).Add(13 * time.Hour), ModerationStatus: models.ModerationRejected},
+	)
+
+	average := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name          string
+		from, to      time.Time
+		total         int
+		perDay        []models.DailyCount
+		perPlatform   map[string]int
+		average       *float64
+		rated         int
+		newUsers      int
+		newWithFB     int
+		newUsersShare *float64
+	}{
+		{
+			name: "whole range leaves out rejected feedback",
+			from: analyticsDay(1), to: analyticsDay(4),
+			total:       4,
+			perDay:      []models.DailyCount{{Date: "2024-03-01", Count: 2}, {Date: "2024-03-02", Count: 1}, {Date: "2024-03-03", Count: 1}},
+			perPlatform: map[string]int{"ios": 2, "android": 1, "web": 1},
+			average:     average(11.0 / 3), rated: 3,
+			newUsers: 2, newWithFB: 1, newUsersShare: average(0.5),
+		},
+		{
+			name: "window starting and ending mid-day",
+			from: analyticsDay(1).Add(12 * time.Hour), to: analyticsDay(2).Add(12 * time.Hour),
+			total:       2,
+			perDay:      []models.DailyCount{{Date: "2024-03-01", Count: 1}, {Date: "2024-03-02", Count: 1}},
+			perPlatform: map[string]int{"android": 1, "ios": 1},
+			average:     average(2), rated: 1,
+			newUsers: 1, newWithFB: 0, newUsersShare: average(0),
+		},
+		{
+			name: "empty window",
+			from: analyticsDay(5), to: analyticsDay(6),
+			perDay:      []models.DailyCount{{Date: "2024-03-05"}},
+			perPlatform: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := analytics.FeedbackAnalytics(tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if result.TotalSubmissions != tt.total {
+			t.Errorf("%s: total = %d, want %d", tt.name, result.TotalSubmissions, tt.total)
+		}
+		if !slices.Equal(result.PerDay, tt.perDay) {
+			t.Errorf("%s: per day = %v, want %v", tt.name, result.PerDay, tt.perDay)
+		}
+		if len(result.PerPlatform) != len(tt.perPlatform) {
+			t.Errorf("%s: per platform = %v, want %v", tt.name, result.PerPlatform, tt.perPlatform)
+		}
+		for platform, count := range tt.perPlatform {
+			if result.PerPlatform[platform] != count {
+				t.Errorf("%s: per platform = %v, want %v", tt.name, result.PerPlatform, tt.perPlatform)
+				break
+			}
+		}
+		if !floatPtrNear(result.AverageRating, tt.average) || result.RatedSubmissions != tt.rated {
+			t.Errorf("%s: average = %v over %d, want %v over %d", tt.name, floatPtrString(result.AverageRating), result.RatedSubmissions, floatPtrString(tt.average), tt.rated)
+		}
+		if result.NewUsers != tt.newUsers || result.NewUsersWithFeedback != tt.newWithFB || !floatPtrNear(result.NewUserFeedbackShare, tt.newUsersShare) {
+			t.Errorf("%s: new users %d with feedback %d share %v, want %d, %d, %v", tt.name,
+				result.NewUsers, result.NewUsersWithFeedback, floatPtrString(result.NewUserFeedbackShare),
+				tt.newUsers, tt.newWithFB, floatPtrString(tt.newUsersShare))
+		}
+	}
+}
+
+func TestFeedbackAnalyticsTopKeywords(t *testing.T) {
+	analytics, feedback := newTestAnalytics(0)
+	seedFeedback(feedback,
+		&models.Feedback{Content: "The app crashes on launch", CreatedAt: analyticsDay(1)},
+		&models.Feedback{Content: "Crashing, crashing and crashing", CreatedAt: analyticsDay(1)},
+		&models.Feedback{Content: "Love the dark mode", CreatedAt: analyticsDay(2)},
+		&models.Feedback{Content: "Dark mode please", CreatedAt: analyticsDay(2)},
+		&models.Feedback{Content: "spam spam spam", CreatedAt: analyticsDay(2), ModerationStatus: models.ModerationRejected},
+	)
+
+	result, err := analytics.FeedbackAnalytics(analyticsDay(1), analyticsDay(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keywords count entries, not occurrences, and show the most common spelling
+	want := []models.KeywordCount{{Keyword: "crashing", Count: 2}, {Keyword: "dark", Count: 2}, {Keyword: "mode", Count: 2}}
+	if len(result.TopKeywords) < len(want) || !slices.Equal(result.TopKeywords[:len(want)], want) {
+		t.Errorf("top keywords = %v, want them to start with %v", result.TopKeywords, want)
+	}
+	for _, keyword := range result.TopKeywords {
+		if keyword.Keyword == "spam" || keyword.Keyword == "the" || keyword.Keyword == "on" {
+			t.Errorf("unexpected keyword %q in %v", keyword.Keyword, result.TopKeywords)
+		}
+	}
+}
+
+func TestFeedbackAnalyticsValidatesWindow(t *testing.T) {
+	analytics, _ := newTestAnalytics(0)
+	start := analyticsDay(1)
+
+	tests := []struct {
+		name  string
+		to    time.Time
+		valid bool
+	}{
+		{"empty", start, false},
+		{"ends before it starts", start.Add(-time.Hour), false},
+		{"one second", start.Add(time.Second), true},
+		{"366 days", start.Add(MaxAnalyticsWindow), true},
+		{"just over 366 days", start.Add(MaxAnalyticsWindow + time.Second), false},
+	}
+	for _, tt := range tests {
+		_, err := analytics.FeedbackAnalytics(start, tt.to)
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.valid && err != ErrInvalidAnalyticsWindow {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, ErrInvalidAnalyticsWindow)
+		}
+	}
+}
+
+func TestFeedbackAnalyticsCachesPerWindow(t *testing.T) {
+	analytics, feedback := newTestAnalytics(time.Hour)
+	seedFeedback(feedback, &models.Feedback{Content: "First", CreatedAt: analyticsDay(1)})
+
+	first, err := analytics.FeedbackAnalytics(analyticsDay(1), analyticsDay(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedFeedback(feedback, &models.Feedback{Content: "Second", CreatedAt: analyticsDay(1).Add(time.Hour)})
+
+	// The same instants in another time zone share the cache entry
+	tokyo := time.FixedZone("JST", 9*60*60)
+	cached, err := analytics.FeedbackAnalytics(analyticsDay(1).In(tokyo), analyticsDay(2).In(tokyo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached != first || cached.TotalSubmissions != 1 {
+		t.Errorf("repeated window was recomputed (total %d), want the cached result", cached.TotalSubmissions)
+	}
+
+	other, err := analytics.FeedbackAnalytics(analyticsDay(1), analyticsDay(2).Add(time.Nanosecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == first || other.TotalSubmissions != 2 {
+		t.Errorf("a different window reused the cached result (total %d)", other.TotalSubmissions)
+	}
+}
+
+func TestFeedbackAnalyticsWithoutCacheRecomputes(t *testing.T) {
+	analytics, feedback := newTestAnalytics(0)
+	seedFeedback(feedback, &models.Feedback{Content: "First", CreatedAt: analyticsDay(1)})
+
+	if _, err := analytics.FeedbackAnalytics(analyticsDay(1), analyticsDay(2)); err != nil {
+		t.Fatal(err)
+	}
+	seedFeedback(feedback, &models.Feedback{Content: "Second", CreatedAt: analyticsDay(1).Add(time.Hour)})
+
+	result, err := analytics.FeedbackAnalytics(analyticsDay(1), analyticsDay(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalSubmissions != 2 {
+		t.Errorf("total = %d with the cache disabled, want 2", result.TotalSubmissions)
+	}
+}
+
+// floatPtrNear reports whether two optional values are both nil or nearly equal
+func floatPtrNear(got, want *float64) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+	diff := *got - *want
+	return diff < 1e-9 && diff > -1e-9
+}
+
+func floatPtrString(v *float64) interface{} {
+	if v == nil {
+		return "nil"
+	}
+	return *v
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAttachmentTooLarge     = errors.New("attachment is too large")
+	ErrAttachmentType         = errors.New("attachment type is not allowed")
+	ErrAttachmentNotFound     = errors.New("attachment not found")
+	ErrInvalidAttachmentLink  = errors.New("invalid or expired attachment link")
+	ErrFeedbackNotOwnedByUser = errors.New("feedback belongs to another user")
+)
+
+// MaxAttachmentSize is the largest file accepted for a single attachment
+const MaxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes are the sniffed media types accepted for upload
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":          true,
+	"image/jpeg":         true,
+	"image/gif":          true,
+	"image/webp":         true,
+	"text/plain":         true,
+	"application/zip":    true,
+	"application/x-gzip": true,
+}
+
+// AttachmentService handles feedback attachment uploads and signed downloads
+type AttachmentService struct {
+	store           BlobStore
+	feedbackService *FeedbackService
+	signingKey      []byte
+	baseURL         string
+	linkTTL         time.Duration                 // download links handed to the uploader
+	slackLinkTTL    time.Duration                 // longer so links in Slack keep working for triage
+	attachments     map[string]*models.Attachment // attachmentID -> attachment
+	logger          *slog.Logger
+	mu              sync.RWMutex
+}
+
+// NewAttachmentService creates a new attachment service whose download links point at baseURL
+func NewAttachmentService(store BlobStore, feedbackService *FeedbackService, cfg config.AttachmentsConfig, baseURL string, logger *slog.Logger) *AttachmentService {
+	return &AttachmentService{
+		store:           store,
+		feedbackService: feedbackService,
+		signingKey:      []byte(cfg.SigningKey),
+		baseURL:         baseURL,
+		linkTTL:         cfg.LinkTTL,
+		slackLinkTTL:    cfg.SlackLinkTTL,
+		attachments:     make(map[string]*models.Attachment),
+		logger:          logger,
+	}
+}
+
+// blobKey returns where an attachment's contents are stored
+func blobKey(a *models.Attachment) string {
+	return path.Join("feedback", a.FeedbackID, a.ID)
+}
+
+// Upload stores a file for a feedback entry owned by the user. The content type is
+// sniffed from the data rather than trusted from the client.
+func (s *AttachmentService) Upload(userID, feedbackID, filename string, r io.Reader) (*models.Attachment, error) {
+	feedback, exists := s.feedbackService.GetFeedback(feedbackID)
+	if !exists {
+		return nil, ErrFeedbackNotFound
+	}
+	if feedback.UserID != userID {
+		return nil, ErrFeedbackNotOwnedByUser
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if n == 0 || !allowedAttachmentTypes[mediaType] {
+		return nil, ErrAttachmentType
+	}
+
+	attachment := &models.Attachment{
+		ID:          uuid.New().String(),
+		FeedbackID:  feedbackID,
+		UserID:      userID,
+		Filename:    sanitizeFilename(filename),
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
+
+	// Read one byte past the limit so oversized files can be detected and removed
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head), r), MaxAttachmentSize+1)
+	size, err := s.store.Put(blobKey(attachment), body)
+	if err != nil {
+		s.store.Delete(blobKey(attachment))
+		return nil, err
+	}
+	if size > MaxAttachmentSize {
+		s.store.Delete(blobKey(attachment))
+		return nil, ErrAttachmentTooLarge
+	}
+	attachment.Size = size
+
+	if err := s.feedbackService.AddAttachment(attachment); err != nil {
+		s.store.Delete(blobKey(attachment))
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.attachments[attachment.ID] = attachment
+	s.mu.Unlock()
+
+	return attachment, nil
+}
+
+// sanitizeFilename keeps the base name of an uploaded file without control characters,
+// cut to at most 255 bytes without splitting a character. Invalid UTF-8 becomes U+FFFD.
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if len(name) > 255 {
+		cut := 255
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	return name
+}
+
+// SignedURL returns a download link for the attachment that expires after ttl
+func (s *AttachmentService) SignedURL(a *models.Attachment, ttl time.Duration) string {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", s.sign(a.ID, expires))
+	return fmt.Sprintf("%s/api/attachments/%s?%s", s.baseURL, a.ID, q.Encode())
+}
+
+// DownloadURL returns a short-lived download link for the uploader
+func (s *AttachmentService) DownloadURL(a *models.Attachment) string {
+	return s.SignedURL(a, s.linkTTL)
+}
+
+// SlackURL returns a long-lived download link for Slack notifications
+func (s *AttachmentService) SlackURL(a *models.Attachment) string {
+	return s.SignedURL(a, s.slackLinkTTL)
+}
+
+// sign computes the download signature for an attachment and expiry
+func (s *AttachmentService) sign(attachmentID, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(attachmentID + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Open verifies a signed download link and opens the attachment's contents
+func (s *AttachmentService) Open(attachmentID, expires, signature string) (*models.Attachment, io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, nil, ErrInvalidAttachmentLink
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(attachmentID, expires))) {
+		return nil, nil, ErrInvalidAttachmentLink
+	}
+
+	s.mu.RLock()
+	attachment, exists := s.attachments[attachmentID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	rc, err := s.store.Get(blobKey(attachment))
+	if err != nil {
+		if err == ErrBlobNotFound {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	return attachment, rc, nil
+}
+
+// DeleteByUser removes every attachment uploaded by a user
+func (s *AttachmentService) DeleteByUser(ctx context.Context, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, attachment := range s.attachments {
+		if attachment.UserID == userID {
+			if err := s.store.Delete(blobKey(attachment)); err != nil {
+				s.logger.WarnContext(ctx, "failed to delete attachment contents", "attachment_id", id, "error", err)
+			}
+			delete(s.attachments, id)
+		}
+	}
+}
+
+// DeleteByFeedback removes every attachment of a feedback entry
+func (s *AttachmentService) DeleteByFeedback(ctx context.Context, feedbackID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, attachment := range s.attachments {
+		if attachment.FeedbackID == feedbackID {
+			if err := s.store.Delete(blobKey(attachment)); err != nil {
+				s.logger.WarnContext(ctx, "failed to delete attachment contents", "attachment_id", id, "error", err)
+			}
+			delete(s.attachments, id)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+// pngHeader is enough of a PNG file for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// newTestAttachments returns an attachment service storing files in a temporary
+// directory, and a feedback entry owned by user-1 to attach them to
+func newTestAttachments(t *testing.T, signingKey string) (*AttachmentService, *models.Feedback, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := NewLocalBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedback, _ := newTestFeedbackService()
+	fb, err := feedback.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "See the screenshot", Platform: "ios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default().Attachments
+	cfg.SigningKey = signingKey
+	return NewAttachmentService(store, feedback, cfg, testBaseURL, newTestLogger()), fb, dir
+}
+
+// storedFiles counts the files below dir
+func storedFiles(t *testing.T, dir string) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	long := strings.Repeat("a", 254) + "é.png"
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"screenshot.png", "screenshot.png"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\jane\crash log.txt`, "crash log.txt"},
+		{"bad\x00name\"\n.png", "badname.png"},
+		{"\xffname.png", "\uFFFDname.png"},
+		{"", "attachment"},
+		{"/", "attachment"},
+		{"dir/.", "attachment"},
+		{strings.Repeat("b", 300), strings.Repeat("b", 255)},
+		{long, strings.Repeat("a", 254)},
+	}
+
+	for _, tt := range tests {
+		got := sanitizeFilename(tt.name)
+		if got != tt.want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if len(got) > 255 || !utf8.ValidString(got) {
+			t.Errorf("sanitizeFilename(%q) = %q, want at most 255 bytes of valid UTF-8", tt.name, got)
+		}
+	}
+}
+
+func TestUploadSniffsContentType(t *testing.T) {
+	s, fb, dir := newTestAttachments(t, "test-key")
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		{"png named as text", pngHeader, "image/png", nil},
+		{"plain text", []byte("Steps to reproduce: open settings"), "text/plain; charset=utf-8", nil},
+		{"html", []byte("<!DOCTYPE html><script>alert(1)</script>"), "", ErrAttachmentType},
+		{"executable", []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00"), "", ErrAttachmentType},
+		{"empty", nil, "", ErrAttachmentType},
+	}
+	for _, tt := range tests {
+		attachment, err := s.Upload("user-1", fb.ID, "notes.txt", bytes.NewReader(tt.data))
+		if err != tt.wantErr {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && attachment.ContentType != tt.want {
+			t.Errorf("%s: content type %q, want %q", tt.name, attachment.ContentType, tt.want)
+		}
+	}
+	if n := storedFiles(t, dir); n != 2 {
+		t.Errorf("%d files stored, want only the 2 accepted uploads", n)
+	}
+}
+
+func TestUploadEnforcesSizeLimitAndOwnership(t *testing.T) {
+	s, fb, dir := newTestAttachments(t, "test-key")
+
+	atLimit, err := s.Upload("user-1", fb.ID, "log.txt", bytes.NewReader(bytes.Repeat([]byte("a"), MaxAttachmentSize)))
+	if err != nil {
+		t.Fatalf("file at the size limit: %v", err)
+	}
+	if atLimit.Size != MaxAttachmentSize {
+		t.Errorf("size = %d, want %d", atLimit.Size, MaxAttachmentSize)
+	}
+
+	if _, err := s.Upload("user-1", fb.ID, "log.txt", bytes.NewReader(bytes.Repeat([]byte("a"), MaxAttachmentSize+1))); err != ErrAttachmentTooLarge {
+		t.Errorf("oversized file: err = %v, want %v", err, ErrAttachmentTooLarge)
+	}
+	if _, err := s.Upload("user-2", fb.ID, "shot.png", bytes.NewReader(pngHeader)); err != ErrFeedbackNotOwnedByUser {
+		t.Errorf("another user's feedback: err = %v, want %v", err, ErrFeedbackNotOwnedByUser)
+	}
+	if _, err := s.Upload("user-1", "missing", "shot.png", bytes.NewReader(pngHeader)); err != ErrFeedbackNotFound {
+		t.Errorf("missing feedback: err = %v, want %v", err, ErrFeedbackNotFound)
+	}
+
+	if n := storedFiles(t, dir); n != 1 {
+		t.Errorf("%d files stored, want only the one within the limit", n)
+	}
+}
+
+// linkParams splits a signed download link into its attachment ID, expiry and signature
+func linkParams(t *testing.T, link string) (id, expires, signature string) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(u.Path, "/api/attachments/"), u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestSignedLinksOpenOnlyUntampered(t *testing.T) {
+	s, fb, _ := newTestAttachments(t, "test-key")
+	attachment, err := s.Upload("user-1", fb.ID, "shot.png", bytes.NewReader(pngHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.Upload("user-1", fb.ID, "other.png", bytes.NewReader(pngHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, expires, signature := linkParams(t, s.DownloadURL(attachment))
+	opened, rc, err := s.Open(id, expires, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if opened.ID != attachment.ID || !bytes.Equal(data, pngHeader) {
+		t.Errorf("opened %s with %d bytes, want %s with the uploaded contents", opened.ID, len(data), attachment.ID)
+	}
+
+	_, expiredAt, expiredSignature := linkParams(t, s.SignedURL(attachment, -time.Minute))
+	otherKey, _, _ := newTestAttachments(t, "other-key")
+	_, otherExpires, otherSignature := linkParams(t, otherKey.SignedURL(attachment, time.Hour))
+	later := time.Now().Add(24 * time.Hour).Unix()
+
+	tests := []struct {
+		name                   string
+		id, expires, signature string
+	}{
+		{"expired", id, expiredAt, expiredSignature},
+		{"another attachment", other.ID, expires, signature},
+		{"extended expiry", id, strconv.FormatInt(later, 10), signature},
+		{"changed signature", id, expires, strings.Repeat("0", len(signature))},
+		{"missing signature", id, expires, ""},
+		{"non-numeric expiry", id, "tomorrow", signature},
+		{"other signing key", id, otherExpires, otherSignature},
+	}
+	for _, tt := range tests {
+		if _, _, err := s.Open(tt.id, tt.expires, tt.signature); err != ErrInvalidAttachmentLink {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, ErrInvalidAttachmentLink)
+		}
+	}
+
+	// Links posted to Slack outlive the uploader's
+	_, slackExpires, _ := linkParams(t, s.SlackURL(attachment))
+	uploaderAt, _ := strconv.ParseInt(expires, 10, 64)
+	slackAt, _ := strconv.ParseInt(slackExpires, 10, 64)
+	if slackAt <= uploaderAt {
+		t.Errorf("Slack link expires at %d, want after the uploader's link at %d", slackAt, uploaderAt)
+	}
+}
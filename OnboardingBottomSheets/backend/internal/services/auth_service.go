@@ -1,13 +1,17 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
+	"onboarding-backend/internal/config"
 	"onboarding-backend/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -16,58 +20,91 @@ import (
 )
 
 var (
-	// JWT secret - In production, use environment variable
-	jwtSecret = []byte("your-secret-key-change-in-production")
-
 	ErrInvalidToken      = errors.New("invalid or expired token")
 	ErrTokenAlreadyUsed  = errors.New("token has already been used")
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+	ErrSessionNotFound   = errors.New("session not found")
+)
+
+// Session login methods
+const (
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodPasskey   = "passkey"
 )
 
+// TokenClaims holds the identity behind an access token. Email and Role are the
+// user's current values, not the ones baked into the token at sign-in.
+type TokenClaims struct {
+	UserID    string
+	Email     string
+	Role      string
+	SessionID string
+}
+
 // AuthService handles authentication logic
 type AuthService struct {
-	users        map[string]*models.User      // email -> user
 	magicLinks   map[string]*models.MagicLink // token -> link
 	rateLimiter  map[string]*rate.Limiter     // email -> limiter
+	sessions     map[string]*models.Session   // sessionID -> session
 	emailService *EmailService
+	userService  *UserService
+	jwtSecret    []byte
+	jwtLifetime  time.Duration // how long an access token (and its session) stays valid
+	linkTTL      time.Duration
+	linkLimit    rate.Limit
+	linkBurst    int
+	baseURL      string
+	logger       *slog.Logger
 	mu           sync.RWMutex
 }
 
-// NewAuthService creates a new auth service
-func NewAuthService(emailService *EmailService) *AuthService {
+// NewAuthService creates a new auth service that signs tokens with the configured
+// secret and applies the configured link expiry, token lifetime and rate limit.
+// Magic links point at baseURL.
+func NewAuthService(emailService *EmailService, userService *UserService, cfg config.AuthConfig, baseURL string, logger *slog.Logger) *AuthService {
 	return &AuthService{
-		users:        make(map[string]*models.User),
 		magicLinks:   make(map[string]*models.MagicLink),
 		rateLimiter:  make(map[string]*rate.Limiter),
+		sessions:     make(map[string]*models.Session),
 		emailService: emailService,
+		userService:  userService,
+		jwtSecret:    []byte(cfg.JWTSecret),
+		jwtLifetime:  cfg.JWTLifetime,
+		linkTTL:      cfg.MagicLinkTTL,
+		linkLimit:    rate.Every(cfg.MagicLinkInterval),
+		linkBurst:    cfg.MagicLinkBurst,
+		baseURL:      baseURL,
+		logger:       logger,
 	}
 }
 
-// getRateLimiter returns rate limiter for an email (5 requests per hour)
+// getRateLimiter returns the magic link rate limiter for an email
 func (s *AuthService) getRateLimiter(email string) *rate.Limiter {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	limiter, exists := s.rateLimiter[email]
+	key := normalizeEmail(email)
+	limiter, exists := s.rateLimiter[key]
 	if !exists {
-		limiter = rate.NewLimiter(rate.Every(12*time.Minute), 5) // 5 per hour
-		s.rateLimiter[email] = limiter
+		limiter = rate.NewLimiter(s.linkLimit, s.linkBurst)
+		s.rateLimiter[key] = limiter
 	}
 	return limiter
 }
 
-// GenerateMagicLink creates a magic link for email authentication
-func (s *AuthService) GenerateMagicLink(email string) (string, error) {
+// GenerateMagicLink creates a magic link for email authentication and returns a copy
+// of it, including when it expires
+func (s *AuthService) GenerateMagicLink(ctx context.Context, email string) (*models.MagicLink, error) {
 	// Rate limiting
 	limiter := s.getRateLimiter(email)
 	if !limiter.Allow() {
-		return "", ErrRateLimitExceeded
+		return nil, ErrRateLimitExceeded
 	}
 
 	// Generate secure random token
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
-		return "", err
+		return nil, err
 	}
 	token := hex.EncodeToString(tokenBytes)
 
@@ -75,121 +112,248 @@ func (s *AuthService) GenerateMagicLink(email string) (string, error) {
 	link := &models.MagicLink{
 		Token:     token,
 		Email:     email,
-		ExpiresAt: time.Now().Add(15 * time.Minute), // 15 minute expiry
+		ExpiresAt: time.Now().Add(s.linkTTL),
 		Used:      false,
 		CreatedAt: time.Now(),
 	}
 
+	// Copy before storing, since verification marks the stored link used
+	linkCopy := *link
 	s.mu.Lock()
 	s.magicLinks[token] = link
 	s.mu.Unlock()
 
 	// Send email with magic link
 	// Use HTTP URL that redirects to deep link (works in email clients)
-	baseURL := getEnv("BASE_URL", "http://localhost:8080")
-	magicLink := fmt.Sprintf("%s/auth/verify?token=%s", baseURL, token)
-	if err := s.emailService.SendMagicLink(email, magicLink, token); err != nil {
+	magicLink := fmt.Sprintf("%s/auth/verify?token=%s", s.baseURL, token)
+	if err := s.emailService.SendMagicLink(ctx, email, magicLink, token, s.linkTTL); err != nil {
 		// Log error but don't fail the request (token is still valid for testing)
-		fmt.Printf("Warning: Failed to send email to %s: %v\n", email, err)
+		s.logger.WarnContext(ctx, "failed to send magic link", "to", email, "error", err)
 	}
 
-	return token, nil
+	return &linkCopy, nil
 }
 
 // VerifyMagicLink verifies a magic link and returns auth token
 func (s *AuthService) VerifyMagicLink(token string) (*models.AuthResponse, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	link, exists := s.magicLinks[token]
 	if !exists {
+		s.mu.Unlock()
 		return nil, ErrInvalidToken
 	}
 
 	// Check if expired
 	if time.Now().After(link.ExpiresAt) {
 		delete(s.magicLinks, token)
+		s.mu.Unlock()
 		return nil, ErrInvalidToken
 	}
 
 	// Check if already used
 	if link.Used {
+		s.mu.Unlock()
 		return nil, ErrTokenAlreadyUsed
 	}
 
 	// Mark as used
 	link.Used = true
+	s.mu.Unlock()
 
 	// Get or create user
-	user, exists := s.users[link.Email]
-	isNewUser := !exists
+	user := s.userService.GetOrCreateUser(link.Email)
 
-	if !exists {
-		user = &models.User{
-			ID:        uuid.New().String(),
-			Email:     link.Email,
-			CreatedAt: time.Now(),
-			IsNewUser: true,
-		}
-		s.users[link.Email] = user
+	return s.startSession(user.ID, LoginMethodMagicLink)
+}
+
+// startSession records a login and a new session for the user and issues its JWT.
+// Signing in also cancels any account deletion that is still in its grace period.
+func (s *AuthService) startSession(userID, method string) (*models.AuthResponse, error) {
+	s.userService.CancelDeletion(userID)
+
+	user, err := s.userService.RecordLogin(userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate JWT token
-	jwtToken, err := s.GenerateJWT(user.ID, user.Email)
+	now := time.Now()
+	session := &models.Session{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Method:    method,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.jwtLifetime),
+	}
+
+	jwtToken, err := s.GenerateJWT(user, session.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	s.mu.Lock()
+	s.sessions[session.ID] = session
+	s.mu.Unlock()
+
 	return &models.AuthResponse{
 		Token:     jwtToken,
 		UserID:    user.ID,
 		Email:     user.Email,
-		IsNewUser: isNewUser,
+		IsNewUser: user.IsNewUser,
 	}, nil
 }
 
-// GenerateJWT creates a JWT token for a user
-func (s *AuthService) GenerateJWT(userID, email string) (string, error) {
+// GenerateJWT creates a JWT token for a user session
+func (s *AuthService) GenerateJWT(user *models.User, sessionID string) (string, error) {
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"email":   email,
-		"exp":     time.Now().Add(30 * 24 * time.Hour).Unix(), // 30 days
+		"user_id": user.ID,
+		"email":   user.Email,
+		"role":    user.Role,
+		"sid":     sessionID,
+		"exp":     time.Now().Add(s.jwtLifetime).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(s.jwtSecret)
 }
 
-// ValidateJWT validates a JWT token and returns user info
-func (s *AuthService) ValidateJWT(tokenString string) (string, string, error) {
+// ValidateJWT validates a JWT token and returns its claims with the user's current
+// email and role, so a demotion or email change applies to existing tokens.
+// Tokens whose session was revoked or deleted, or whose user no longer exists, are rejected.
+func (s *AuthService) ValidateJWT(tokenString string) (*TokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
-		return jwtSecret, nil
+		return s.jwtSecret, nil
 	})
 
 	if err != nil || !token.Valid {
-		return "", "", ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", "", ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
-	userID, _ := claims["user_id"].(string)
-	email, _ := claims["email"].(string)
+	result := &TokenClaims{}
+	result.UserID, _ = claims["user_id"].(string)
+	result.SessionID, _ = claims["sid"].(string)
+
+	s.mu.RLock()
+	session, exists := s.sessions[result.SessionID]
+	s.mu.RUnlock()
+	if !exists || session.UserID != result.UserID || session.RevokedAt != nil {
+		return nil, ErrInvalidToken
+	}
 
-	return userID, email, nil
+	user, exists := s.userService.GetUserByID(result.UserID)
+	if !exists {
+		return nil, ErrInvalidToken
+	}
+	result.Email = user.Email
+	result.Role = user.Role
+
+	return result, nil
 }
 
-// GetUserByEmail returns a user by email
-func (s *AuthService) GetUserByEmail(email string) (*models.User, bool) {
+// RefreshSession issues a new JWT for an existing session and extends its expiry.
+// The user's current email and role are used so changes since login are picked up.
+func (s *AuthService) RefreshSession(claims *TokenClaims) (string, *models.User, error) {
+	user, exists := s.userService.GetUserByID(claims.UserID)
+	if !exists {
+		return "", nil, ErrUserNotFound
+	}
+
+	jwtToken, err := s.GenerateJWT(user, claims.SessionID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mu.Lock()
+	if session, exists := s.sessions[claims.SessionID]; exists {
+		session.ExpiresAt = time.Now().Add(s.jwtLifetime)
+	}
+	s.mu.Unlock()
+
+	return jwtToken, user, nil
+}
+
+// GetSessionsByUser returns all sessions of a user, including revoked ones
+func (s *AuthService) GetSessionsByUser(userID string) []*models.Session {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	user, exists := s.users[email]
-	return user, exists
+	var result []*models.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			copied := *session
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// RevokeUserSessions revokes every active session of a user and returns how many were revoked
+func (s *AuthService) RevokeUserSessions(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	revoked := 0
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// RevokeSession revokes a single session of a user
+func (s *AuthService) RevokeSession(userID, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[sessionID]
+	if !exists || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	if session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+// DeleteUserData removes the sessions, magic links and rate limiter state of a user
+func (s *AuthService) DeleteUserData(userID, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+	for token, link := range s.magicLinks {
+		if normalizeEmail(link.Email) == normalizeEmail(email) {
+			delete(s.magicLinks, token)
+		}
+	}
+	delete(s.rateLimiter, normalizeEmail(email))
+}
+
+// GetUserByEmail returns a user by email
+func (s *AuthService) GetUserByEmail(email string) (*models.User, bool) {
+	return s.userService.GetUserByEmail(email)
+}
+
+// GetUserByID returns a user by ID
+func (s *AuthService) GetUserByID(userID string) (*models.User, bool) {
+	return s.userService.GetUserByID(userID)
 }
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+func newTestAuthService() (*AuthService, *UserService) {
+	logger := newTestLogger()
+	emailService, userService := newTestUsers(logger, config.RolesConfig{AdminEmails: []string{"admin@example.com"}})
+	cfg := config.Default().Auth
+	cfg.JWTSecret = "test-secret"
+	return NewAuthService(emailService, userService, cfg, testBaseURL, logger), userService
+}
+
+// signIn completes a magic link login and returns the access token
+func signIn(t *testing.T, s *AuthService, email string) *models.AuthResponse {
+	t.Helper()
+	link, err := s.GenerateMagicLink(context.Background(), email)
+	if err != nil {
+		t.Fatalf("GenerateMagicLink: %v", err)
+	}
+	resp, err := s.VerifyMagicLink(link.Token)
+	if err != nil {
+		t.Fatalf("VerifyMagicLink: %v", err)
+	}
+	return resp
+}
+
+func TestValidateJWTUsesCurrentRoleAndEmail(t *testing.T) {
+	s, userService := newTestAuthService()
+	resp := signIn(t, s, "admin@example.com")
+
+	claims, err := s.ValidateJWT(resp.Token)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if claims.Role != models.RoleAdmin || claims.Email != "admin@example.com" {
+		t.Fatalf("claims = %+v, want admin@example.com as admin", claims)
+	}
+
+	if _, err := userService.SetRole(resp.UserID, models.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if err := userService.RequestEmailChange(context.Background(), resp.UserID, "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	var token string
+	for pending := range userService.emailChanges {
+		token = pending
+	}
+	if _, err := userService.ConfirmEmailChange(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err = s.ValidateJWT(resp.Token)
+	if err != nil {
+		t.Fatalf("ValidateJWT after changes: %v", err)
+	}
+	if claims.Role != models.RoleUser {
+		t.Errorf("role = %q after demotion, want %q", claims.Role, models.RoleUser)
+	}
+	if claims.Email != "new@example.com" {
+		t.Errorf("email = %q after email change, want new@example.com", claims.Email)
+	}
+}
+
+func TestValidateJWTRejectsDeletedUser(t *testing.T) {
+	s, userService := newTestAuthService()
+	resp := signIn(t, s, "user@example.com")
+
+	if _, err := userService.DeleteUser(resp.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateJWT(resp.Token); err != ErrInvalidToken {
+		t.Errorf("ValidateJWT for a deleted user = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestIsNewUserLastsUntilOnboardingIsCompleted(t *testing.T) {
+	s, userService := newTestAuthService()
+
+	first := signIn(t, s, "user@example.com")
+	if !first.IsNewUser {
+		t.Error("first sign-in: is_new_user = false, want true")
+	}
+	firstUser, _ := userService.GetUserByID(first.UserID)
+
+	// Signing in again without finishing onboarding still counts as new
+	if again := signIn(t, s, "user@example.com"); !again.IsNewUser {
+		t.Error("second sign-in before onboarding: is_new_user = false, want true")
+	}
+	user, _ := userService.GetUserByID(first.UserID)
+	if user.LoginCount != 2 || !user.FirstLoginAt.Equal(*firstUser.FirstLoginAt) || user.LastLoginAt.Before(*firstUser.LastLoginAt) {
+		t.Errorf("after two sign-ins: count %d, first %v, last %v", user.LoginCount, user.FirstLoginAt, user.LastLoginAt)
+	}
+
+	completed, err := userService.CompleteOnboarding(first.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed.IsNewUser || completed.OnboardingCompletedAt == nil {
+		t.Errorf("after onboarding: is_new_user %v, completed at %v", completed.IsNewUser, completed.OnboardingCompletedAt)
+	}
+	if later := signIn(t, s, "user@example.com"); later.IsNewUser {
+		t.Error("sign-in after onboarding: is_new_user = true, want false")
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// Background runs fire-and-forget work, such as publishing to Slack or delivering
+// webhooks, so that shutdown can wait for it to finish instead of cutting it off
+type Background struct {
+	wg      sync.WaitGroup
+	running int
+	closed  bool
+	mu      sync.Mutex
+}
+
+// NewBackground creates a tracker for background work
+func NewBackground() *Background {
+	return &Background{}
+}
+
+// Go runs fn in a new goroutine. Once Wait has been called no new work is started, and
+// Go reports false.
+func (b *Background) Go(fn func()) bool {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return false
+	}
+	b.running++
+	b.wg.Add(1)
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			b.running--
+			b.mu.Unlock()
+			b.wg.Done()
+		}()
+		fn()
+	}()
+	return true
+}
+
+// Wait stops new work from starting and waits for running work to finish. If ctx ends
+// first, it returns the context's error and how much work was still running.
+func (b *Background) Wait(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return 0, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.running, ctx.Err()
+	}
+}
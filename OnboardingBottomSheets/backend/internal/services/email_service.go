@@ -2,52 +2,132 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
+	"log/slog"
 	"net/smtp"
-	"os"
+	"strings"
+	"time"
+
+	"onboarding-backend/internal/config"
 )
 
 // EmailService handles sending emails
 type EmailService struct {
 	smtpHost     string
-	smtpPort     string
+	smtpPort     int
 	smtpUsername string
 	smtpPassword string
 	fromEmail    string
 	fromName     string
+	logger       *slog.Logger
 }
 
-// NewEmailService creates a new email service
-func NewEmailService() *EmailService {
+// NewEmailService creates a new email service. Without SMTP credentials, emails are
+// logged instead of sent.
+func NewEmailService(cfg config.EmailConfig, logger *slog.Logger) *EmailService {
 	return &EmailService{
-		smtpHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
-		smtpPort:     getEnv("SMTP_PORT", "587"),
-		smtpUsername: getEnv("SMTP_USERNAME", ""),
-		smtpPassword: getEnv("SMTP_PASSWORD", ""),
-		fromEmail:    getEnv("FROM_EMAIL", "noreply@yourapp.com"),
-		fromName:     getEnv("FROM_NAME", "Onboarding App"),
+		smtpHost:     cfg.SMTPHost,
+		smtpPort:     cfg.SMTPPort,
+		smtpUsername: cfg.SMTPUsername,
+		smtpPassword: cfg.SMTPPassword,
+		fromEmail:    cfg.FromEmail,
+		fromName:     cfg.FromName,
+		logger:       logger,
 	}
 }
 
-// SendMagicLink sends a magic link email to the user
-func (e *EmailService) SendMagicLink(toEmail, magicLink, token string) error {
+// SendMagicLink sends a magic link email to the user, stating how long the link is valid for
+func (e *EmailService) SendMagicLink(ctx context.Context, toEmail, magicLink, token string, ttl time.Duration) error {
 	// Check if email is configured
 	if e.smtpUsername == "" || e.smtpPassword == "" {
-		fmt.Printf("⚠️  Email not configured. Magic link for %s:\n", toEmail)
-		fmt.Printf("🔗 %s\n", magicLink)
-		fmt.Printf("🎫 Token: %s\n", token)
+		// The link and token are redacted unless logging.redact is off
+		e.logger.InfoContext(ctx, "email not configured, magic link not sent", "to", toEmail, "link", magicLink, "token", token)
 		return nil // Don't fail if email isn't configured
 	}
 
 	subject := "Your Login Link"
-	body := e.generateMagicLinkHTML(magicLink, token)
+	body := e.generateMagicLinkHTML(magicLink, token, ttl)
+
+	return e.sendEmail(ctx, toEmail, subject, body)
+}
+
+// SendEmailChangeVerification sends a link that confirms a new email address
+func (e *EmailService) SendEmailChangeVerification(ctx context.Context, toEmail, confirmLink string) error {
+	if e.smtpUsername == "" || e.smtpPassword == "" {
+		e.logger.InfoContext(ctx, "email not configured, email change confirmation not sent", "to", toEmail, "link", confirmLink)
+		return nil
+	}
+
+	body := renderNoticeHTML(
+		"Confirm Your New Email",
+		"Click the button below to use this address for your account. Your old address stays active until you confirm.",
+		confirmLink,
+		"Confirm Email Address",
+	)
+	return e.sendEmail(ctx, toEmail, "Confirm your new email address", body)
+}
+
+// SendEmailChangedNotice tells the previous address that the account email was changed
+func (e *EmailService) SendEmailChangedNotice(ctx context.Context, oldEmail, newEmail string) error {
+	if e.smtpUsername == "" || e.smtpPassword == "" {
+		e.logger.InfoContext(ctx, "email not configured, email changed notice not sent", "to", oldEmail, "new_email", newEmail)
+		return nil
+	}
+
+	body := renderNoticeHTML(
+		"Your Email Was Changed",
+		fmt.Sprintf("The email address for your account was changed to %s. If you didn't make this change, please contact support right away.", newEmail),
+		"",
+		"",
+	)
+	return e.sendEmail(ctx, oldEmail, "Your account email was changed", body)
+}
+
+// SendAccountDeletionScheduled confirms a deletion request and explains how to undo it
+func (e *EmailService) SendAccountDeletionScheduled(ctx context.Context, toEmail string, deleteAt time.Time) error {
+	if e.smtpUsername == "" || e.smtpPassword == "" {
+		e.logger.InfoContext(ctx, "email not configured, deletion notice not sent", "to", toEmail, "delete_at", deleteAt)
+		return nil
+	}
+
+	body := renderNoticeHTML(
+		"Your Account Will Be Deleted",
+		fmt.Sprintf("Your account and all of its data will be permanently deleted on %s. If you change your mind, just sign in again before then.", deleteAt.Format("January 2, 2006")),
+		"",
+		"",
+	)
+	return e.sendEmail(ctx, toEmail, "Your account is scheduled for deletion", body)
+}
+
+// SendFeedbackReply tells a user that someone replied to their feedback
+func (e *EmailService) SendFeedbackReply(ctx context.Context, toEmail, feedbackContent, replyContent string) error {
+	if e.smtpUsername == "" || e.smtpPassword == "" {
+		e.logger.InfoContext(ctx, "email not configured, reply notification not sent", "to", toEmail)
+		return nil
+	}
 
-	return e.sendEmail(toEmail, subject, body)
+	body := renderNoticeHTML(
+		"New Reply to Your Feedback",
+		fmt.Sprintf("You wrote: \"%s\". Our reply: %s", excerpt(feedbackContent, 200), replyContent),
+		"",
+		"",
+	)
+	return e.sendEmail(ctx, toEmail, "We replied to your feedback", body)
+}
+
+// excerpt shortens s to at most n runes, adding an ellipsis when truncated
+func excerpt(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
 }
 
 // sendEmail sends an email via SMTP
-func (e *EmailService) sendEmail(to, subject, body string) error {
+func (e *EmailService) sendEmail(ctx context.Context, to, subject, body string) error {
 	// Build email message
 	headers := make(map[string]string)
 	headers["From"] = fmt.Sprintf("%s <%s>", e.fromName, e.fromEmail)
@@ -66,18 +146,18 @@ func (e *EmailService) sendEmail(to, subject, body string) error {
 	auth := smtp.PlainAuth("", e.smtpUsername, e.smtpPassword, e.smtpHost)
 
 	// Send email
-	addr := fmt.Sprintf("%s:%s", e.smtpHost, e.smtpPort)
+	addr := fmt.Sprintf("%s:%d", e.smtpHost, e.smtpPort)
 	err := smtp.SendMail(addr, auth, e.fromEmail, []string{to}, []byte(message))
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
-	fmt.Printf("✅ Email sent to %s\n", to)
+	e.logger.InfoContext(ctx, "email sent", "to", to, "subject", subject)
 	return nil
 }
 
 // generateMagicLinkHTML generates the HTML email template
-func (e *EmailService) generateMagicLinkHTML(magicLink, token string) string {
+func (e *EmailService) generateMagicLinkHTML(magicLink, token string, ttl time.Duration) string {
 	tmpl := `
 <!DOCTYPE html>
 <html>
@@ -119,7 +199,7 @@ func (e *EmailService) generateMagicLinkHTML(magicLink, token string) string {
         
         <div style="margin-top: 30px; padding-top: 20px; border-top: 1px solid #dee2e6;">
             <p style="margin: 0 0 10px 0; font-size: 14px; color: #666;">
-                <strong>⏱️ This link will expire in 15 minutes</strong>
+                <strong>⏱️ This link will expire in {{.ExpiresIn}}</strong>
             </p>
             <p style="margin: 0 0 10px 0; font-size: 14px; color: #666;">
                 🔒 This link can only be used once for security reasons
@@ -138,9 +218,11 @@ func (e *EmailService) generateMagicLinkHTML(magicLink, token string) string {
 	data := struct {
 		MagicLink string
 		Token     string
+		ExpiresIn string
 	}{
 		MagicLink: magicLink,
 		Token:     token,
+		ExpiresIn: humanDuration(ttl),
 	}
 
 	t := template.Must(template.New("email").Parse(tmpl))
@@ -149,11 +231,80 @@ func (e *EmailService) generateMagicLinkHTML(magicLink, token string) string {
 	return buf.String()
 }
 
-// getEnv gets an environment variable with a default value
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// humanDuration spells out a duration for an email, e.g. "15 minutes" or
+// "1 hour 30 minutes". Seconds are dropped.
+func humanDuration(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+
+	var parts []string
+	for _, unit := range units {
+		n := int(d / unit.size)
+		d -= time.Duration(n) * unit.size
+		switch {
+		case n == 1:
+			parts = append(parts, "1 "+unit.name)
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, unit.name))
+		}
 	}
-	return value
+	if len(parts) == 0 {
+		return "less than a minute"
+	}
+	return strings.Join(parts, " ")
+}
+
+// renderNoticeHTML renders a short notification email with an optional call-to-action button
+func renderNoticeHTML(heading, message, actionURL, actionLabel string) string {
+	tmpl := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Heading}}</title>
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+    <div style="background-color: #f8f9fa; border-radius: 10px; padding: 30px; margin-bottom: 20px;">
+        <h1 style="color: #2A75CF; margin: 0 0 20px 0; font-size: 24px;">{{.Heading}}</h1>
+        <p style="margin: 0 0 20px 0; font-size: 16px;">{{.Message}}</p>
+        {{if .ActionURL}}
+        <div style="text-align: center; margin: 30px 0;">
+            <a href="{{.ActionURL}}"
+               style="display: inline-block; background-color: #2A75CF; color: #ffffff !important; padding: 14px 28px; text-decoration: none; border-radius: 8px; font-weight: 600; font-size: 16px; margin: 10px 0;">
+                {{.ActionLabel}}
+            </a>
+        </div>
+        {{end}}
+    </div>
+
+    <div style="text-align: center; color: #999; font-size: 12px; margin-top: 20px;">
+        <p style="margin: 5px 0;">This is an automated email, please do not reply.</p>
+    </div>
+</body>
+</html>
+`
+
+	data := struct {
+		Heading     string
+		Message     string
+		ActionURL   string
+		ActionLabel string
+	}{
+		Heading:     heading,
+		Message:     message,
+		ActionURL:   actionURL,
+		ActionLabel: actionLabel,
+	}
+
+	t := template.Must(template.New("notice").Parse(tmpl))
+	var buf bytes.Buffer
+	t.Execute(&buf, data)
+	return buf.String()
 }
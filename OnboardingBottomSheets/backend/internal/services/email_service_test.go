@@ -0,0 +1,35 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+)
+
+func TestMagicLinkEmailShowsConfiguredTTL(t *testing.T) {
+	e := NewEmailService(config.EmailConfig{}, newTestLogger())
+
+	tests := []struct {
+		ttl  time.Duration
+		want string
+	}{
+		{15 * time.Minute, "15 minutes"},
+		{time.Minute + 30*time.Second, "1 minute"},
+		{90 * time.Minute, "1 hour 30 minutes"},
+		{2 * time.Hour, "2 hours"},
+		{25*time.Hour + time.Minute, "1 day 1 hour 1 minute"},
+		{30 * time.Second, "less than a minute"},
+	}
+	for _, tt := range tests {
+		if got := humanDuration(tt.ttl); got != tt.want {
+			t.Errorf("humanDuration(%s) = %q, want %q", tt.ttl, got, tt.want)
+		}
+
+		body := e.generateMagicLinkHTML(testBaseURL+"/auth/verify?token=abc", "abc", tt.ttl)
+		if !strings.Contains(body, "This link will expire in "+tt.want+"<") {
+			t.Errorf("email for a %s link doesn't say it expires in %s", tt.ttl, tt.want)
+		}
+	}
+}
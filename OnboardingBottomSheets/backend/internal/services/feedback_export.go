@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"onboarding-backend/internal/models"
+)
+
+// FeedbackExportWriter writes feedback one entry at a time in an export format
+type FeedbackExportWriter interface {
+	Write(fb *models.Feedback) error
+	// Flush writes any buffered data
+	Flush() error
+}
+
+// feedbackCSVHeader lists the CSV export columns
+var feedbackCSVHeader = []string{
+	"id", "created_at", "edited_at", "user_id", "email", "platform", "rating", "category",
+	"screen", "tags", "status", "moderation_status", "attachments", "replies", "content",
+}
+
+// csvFeedbackWriter writes feedback as CSV rows
+type csvFeedbackWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// NewCSVFeedbackWriter creates a writer for CSV with a header row
+func NewCSVFeedbackWriter(w io.Writer) FeedbackExportWriter {
+	return &csvFeedbackWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvFeedbackWriter) Write(fb *models.Feedback) error {
+	if !cw.headerWritten {
+		if err := cw.w.Write(feedbackCSVHeader); err != nil {
+			return err
+		}
+		cw.headerWritten = true
+	}
+
+	editedAt := ""
+	if fb.EditedAt != nil {
+		editedAt = fb.EditedAt.UTC().Format(time.RFC3339)
+	}
+	rating := ""
+	if fb.Rating > 0 {
+		rating = strconv.Itoa(fb.Rating)
+	}
+
+	return cw.w.Write([]string{
+		fb.ID,
+		fb.CreatedAt.UTC().Format(time.RFC3339),
+		editedAt,
+		fb.UserID,
+		csvSafe(fb.Email),
+		csvSafe(fb.Platform),
+		rating,
+		fb.Category,
+		csvSafe(fb.Screen),
+		csvSafe(strings.Join(fb.Tags, ";")),
+		fb.Status,
+		fb.ModerationStatus,
+		strconv.Itoa(len(fb.Attachments)),
+		strconv.Itoa(len(fb.Replies)),
+		csvSafe(fb.Content),
+	})
+}
+
+func (cw *csvFeedbackWriter) Flush() error {
+	// An empty export still gets its header
+	if !cw.headerWritten {
+		if err := cw.w.Write(feedbackCSVHeader); err != nil {
+			return err
+		}
+		cw.headerWritten = true
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// csvSafe stops spreadsheets from treating user text as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// jsonlFeedbackWriter writes feedback as one JSON object per line
+type jsonlFeedbackWriter struct {
+	enc *json.Encoder
+}
+
+// NewJSONLFeedbackWriter creates a writer for JSON Lines (NDJSON)
+func NewJSONLFeedbackWriter(w io.Writer) FeedbackExportWriter {
+	return &jsonlFeedbackWriter{enc: json.NewEncoder(w)}
+}
+
+func (jw *jsonlFeedbackWriter) Write(fb *models.Feedback) error {
+	return jw.enc.Encode(fb)
+}
+
+func (jw *jsonlFeedbackWriter) Flush() error {
+	return nil
+}
+
+// ExportFeedback calls fn for every entry matching the query, newest first. Entries are
+// read a page at a time so the whole result is never held in memory. q.Cursor and
+// q.Limit are ignored.
+func (s *FeedbackService) ExportFeedback(q FeedbackQuery, fn func(fb *models.Feedback) error) error {
+	q.Cursor = ""
+	q.Limit = MaxFeedbackPageSize
+
+	for {
+		page, err := s.ListFeedback(q)
+		if err != nil {
+			return err
+		}
+		for _, fb := range page.Feedback {
+			if err := fn(fb); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/models"
+)
+
+func TestCSVSafe(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"=SUM(A1:A9)", "'=SUM(A1:A9)"},
+		{"+1 555 0100", "'+1 555 0100"},
+		{"-2+3", "'-2+3"},
+		{"@cmd", "'@cmd"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"", ""},
+		{"Works well", "Works well"},
+		{"a=b", "a=b"},
+		{" =1", " =1"},
+	}
+
+	for _, tt := range tests {
+		if got := csvSafe(tt.value); got != tt.want {
+			t.Errorf("csvSafe(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCSVFeedbackWriterWritesHeaderForEmptyExport(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVFeedbackWriter(&buf)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := strings.Join(feedbackCSVHeader, ",") + "\n"; buf.String() != want {
+		t.Errorf("empty export = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestCSVFeedbackWriterWritesRows(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVFeedbackWriter(&buf)
+	edited := time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC)
+	err := w.Write(&models.Feedback{
+		ID:               "fb-1",
+		CreatedAt:        time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)),
+		EditedAt:         &edited,
+		UserID:           "user-1",
+		Email:            "jane@example.com",
+		Platform:         "ios",
+		Rating:           4,
+		Category:         "bug",
+		Screen:           "=Settings",
+		Tags:             []string{"crash", "login"},
+		Status:           models.FeedbackStatusNew,
+		ModerationStatus: models.ModerationApproved,
+		Replies:          []*models.FeedbackReply{{ID: "reply-1"}},
+		Content:          "=HYPERLINK(\"http://evil\"), with \"quotes\"\nand a newline",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(&models.Feedback{ID: "fb-2", CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want a header and 2 rows", len(records))
+	}
+	want := []string{
+		"fb-1", "2024-03-01T11:00:00Z", "2024-03-02T09:30:00Z", "user-1", "jane@example.com", "ios", "4", "bug",
+		"'=Settings", "crash;login", "new", "approved", "0", "1",
+		"'=HYPERLINK(\"http://evil\"), with \"quotes\"\nand a newline",
+	}
+	for i, column := range feedbackCSVHeader {
+		if records[1][i] != want[i] {
+			t.Errorf("%s = %q, want %q", column, records[1][i], want[i])
+		}
+	}
+	if rating, edited := records[2][6], records[2][2]; rating != "" || edited != "" {
+		t.Errorf("unrated, unedited entry has rating %q and edited_at %q, want them empty", rating, edited)
+	}
+}
+
+func TestJSONLFeedbackWriterWritesOneObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLFeedbackWriter(&buf)
+	for _, id := range []string{"fb-1", "fb-2"} {
+		if err := w.Write(&models.Feedback{ID: id, Content: "Line one\nline two"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var fb models.Feedback
+		if err := json.Unmarshal([]byte(line), &fb); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if want := []string{"fb-1", "fb-2"}[i]; fb.ID != want || fb.Content != "Line one\nline two" {
+			t.Errorf("line %d decoded to %s %q, want %s", i+1, fb.ID, fb.Content, want)
+		}
+	}
+}
+
+func TestExportFeedbackReadsEveryPage(t *testing.T) {
+	s, _ := newTestFeedbackService()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2*MaxFeedbackPageSize+10; i++ {
+		platform := models.PlatformIOS
+		if i%2 == 1 {
+			platform = models.PlatformWeb
+		}
+		seedFeedback(s, &models.Feedback{Platform: platform, CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	var exported []*models.Feedback
+	err := s.ExportFeedback(FeedbackQuery{Platform: models.PlatformIOS, Cursor: "ignored", Limit: 1}, func(fb *models.Feedback) error {
+		exported = append(exported, fb)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exported) != MaxFeedbackPageSize+5 {
+		t.Fatalf("exported %d entries, want %d", len(exported), MaxFeedbackPageSize+5)
+	}
+	for i, fb := range exported {
+		if fb.Platform != models.PlatformIOS {
+			t.Fatalf("exported %s feedback with a platform filter of ios", fb.Platform)
+		}
+		if i > 0 && !fb.CreatedAt.Before(exported[i-1].CreatedAt) {
+			t.Fatalf("entry %d is not older than the one before it", i)
+		}
+	}
+}
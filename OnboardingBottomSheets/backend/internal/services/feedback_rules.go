@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"onboarding-backend/internal/models"
+)
+
+var (
+	ErrInvalidFeedbackRule = errors.New("invalid feedback rule")
+)
+
+// FeedbackRule selects feedback for an integration, such as which entries become
+// tickets or which Slack channel an entry goes to. Every condition that is set must match.
+type FeedbackRule struct {
+	Category  string
+	Platform  string
+	Screen    string
+	Tag       string
+	MaxRating int      // matches rated feedback at or below this rating
+	Keywords  []string // stemmed; matches when the content contains any of them
+}
+
+// Matches reports whether feedback meets every condition of the rule
+func (r FeedbackRule) Matches(feedback *models.Feedback) bool {
+	if r.Category != "" && feedback.Category != r.Category {
+		return false
+	}
+	if r.Platform != "" && !strings.EqualFold(feedback.Platform, r.Platform) {
+		return false
+	}
+	if r.Screen != "" && feedback.Screen != r.Screen {
+		return false
+	}
+	if r.Tag != "" && !slices.Contains(feedback.Tags, r.Tag) {
+		return false
+	}
+	if r.MaxRating > 0 && (feedback.Rating == 0 || feedback.Rating > r.MaxRating) {
+		return false
+	}
+	if len(r.Keywords) > 0 {
+		return slices.ContainsFunc(tokenize(feedback.Content), func(token searchToken) bool {
+			return slices.Contains(r.Keywords, token.term)
+		})
+	}
+	return true
+}
+
+// ParseFeedbackRule parses a comma-separated list of key=value conditions, e.g.
+// "category=bug,platform=ios". Keys are category, platform, screen, tag, max_rating and
+// keyword, whose alternatives are separated by "|" ("keyword=crash|freeze"). Keywords
+// are stemmed, so "crash" also matches "crashes".
+func ParseFeedbackRule(spec string) (FeedbackRule, error) {
+	var rule FeedbackRule
+	for _, condition := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(condition, "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return FeedbackRule{}, fmt.Errorf("%w: %q", ErrInvalidFeedbackRule, condition)
+		}
+		switch key {
+		case "category":
+			rule.Category = value
+		case "platform":
+			rule.Platform = value
+		case "screen":
+			rule.Screen = value
+		case "tag":
+			rule.Tag = strings.ToLower(value)
+		case "max_rating":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 5 {
+				return FeedbackRule{}, fmt.Errorf("%w: max_rating must be 1-5", ErrInvalidFeedbackRule)
+			}
+			rule.MaxRating = n
+		case "keyword":
+			for _, word := range strings.Split(value, "|") {
+				tokens := tokenize(word)
+				if len(tokens) != 1 {
+					return FeedbackRule{}, fmt.Errorf("%w: keyword %q must be a single word", ErrInvalidFeedbackRule, word)
+				}
+				rule.Keywords = append(rule.Keywords, tokens[0].term)
+			}
+		default:
+			return FeedbackRule{}, fmt.Errorf("%w: unknown key %q", ErrInvalidFeedbackRule, key)
+		}
+	}
+	return rule, nil
+}
@@ -1,67 +1,683 @@
 package services
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"onboarding-backend/internal/config"
 	"onboarding-backend/internal/models"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrFeedbackNotFound   = errors.New("feedback not found")
+	ErrTooManyAttachments = errors.New("too many attachments")
+	ErrEditWindowClosed   = errors.New("feedback can no longer be changed")
+	ErrInvalidTransition  = errors.New("invalid status transition")
+	ErrReplyNotFound      = errors.New("reply not found")
+)
+
+const (
+	// DefaultFeedbackPageSize is used when a listing doesn't ask for a page size
+	DefaultFeedbackPageSize = 20
+
+	// MaxFeedbackPageSize caps how many entries a single listing can return
+	MaxFeedbackPageSize = 100
+
+	// MaxAttachmentsPerFeedback caps how many files can be attached to one entry
+	MaxAttachmentsPerFeedback = 5
+)
+
+// feedbackTransitions lists the statuses each status can move to
+var feedbackTransitions = map[string][]string{
+	models.FeedbackStatusNew:        {models.FeedbackStatusTriaged, models.FeedbackStatusInProgress, models.FeedbackStatusResolved, models.FeedbackStatusWontFix},
+	models.FeedbackStatusTriaged:    {models.FeedbackStatusInProgress, models.FeedbackStatusResolved, models.FeedbackStatusWontFix},
+	models.FeedbackStatusInProgress: {models.FeedbackStatusTriaged, models.FeedbackStatusResolved, models.FeedbackStatusWontFix},
+	models.FeedbackStatusResolved:   {models.FeedbackStatusTriaged}, // reopen
+	models.FeedbackStatusWontFix:    {models.FeedbackStatusTriaged}, // reopen
+}
+
+// FeedbackQuery selects a page of feedback, newest first
+type FeedbackQuery struct {
+	UserID     string    // empty for all users
+	Platform   string    // empty for all platforms
+	Category   string    // empty for all categories
+	Screen     string    // empty for all screens
+	Tag        string    // empty for any tags
+	Status     string    // empty for any status
+	Moderation string    // empty for any moderation state
+	MinRating  int       // 0 for no lower bound; unrated feedback is excluded when set
+	MaxRating  int       // 0 for no upper bound
+	From       time.Time // inclusive, zero for no lower bound
+	To         time.Time // exclusive, zero for no upper bound
+	Cursor     string    // from a previous page's NextCursor
+	Limit      int
+}
+
+// matches reports whether fb passes the query's field filters
+func (q *FeedbackQuery) matches(fb *models.Feedback) bool {
+	if q.Platform != "" && !strings.EqualFold(fb.Platform, q.Platform) {
+		return false
+	}
+	if q.Category != "" && fb.Category != q.Category {
+		return false
+	}
+	if q.Screen != "" && fb.Screen != q.Screen {
+		return false
+	}
+	if q.MinRating > 0 && fb.Rating < q.MinRating {
+		return false
+	}
+	if q.MaxRating > 0 && (fb.Rating == 0 || fb.Rating > q.MaxRating) {
+		return false
+	}
+	if q.Status != "" && fb.Status != q.Status {
+		return false
+	}
+	if q.Moderation != "" && fb.ModerationStatus != q.Moderation {
+		return false
+	}
+	if q.Tag != "" && !slices.Contains(fb.Tags, strings.ToLower(q.Tag)) {
+		return false
+	}
+	return true
+}
+
+// FeedbackPage is one page of a feedback listing
+type FeedbackPage struct {
+	Feedback   []*models.Feedback
+	NextCursor string // empty on the last page
+}
+
 // FeedbackService handles feedback storage
 type FeedbackService struct {
-	feedback map[string]*models.Feedback // feedbackID -> feedback
-	mu       sync.RWMutex
+	feedback     map[string]*models.Feedback   // feedbackID -> feedback
+	all          []*models.Feedback            // all feedback, oldest first
+	byUser       map[string][]*models.Feedback // userID -> feedback, oldest first
+	userService  *UserService
+	emailService *EmailService
+	events       EventPublisher
+	checks       []ModerationCheck // run in order on new feedback
+	editWindow   time.Duration     // how long after submitting the owner can edit or delete
+	index        *SearchIndex
+	logger       *slog.Logger
+	mu           sync.RWMutex
 }
 
-// NewFeedbackService creates a new feedback service
-func NewFeedbackService() *FeedbackService {
+// NewFeedbackService creates a new feedback service that moderates new feedback with checks.
+// Approved feedback is published to events, and owners can change feedback for the
+// configured edit window.
+func NewFeedbackService(userService *UserService, emailService *EmailService, events EventPublisher, cfg config.FeedbackConfig, logger *slog.Logger, checks ...ModerationCheck) *FeedbackService {
 	return &FeedbackService{
-		feedback: make(map[string]*models.Feedback),
+		feedback:     make(map[string]*models.Feedback),
+		byUser:       make(map[string][]*models.Feedback),
+		userService:  userService,
+		emailService: emailService,
+		events:       events,
+		checks:       checks,
+		editWindow:   cfg.EditWindow,
+		index:        NewSearchIndex(),
+		logger:       logger,
+	}
+}
+
+// copyFeedback returns a snapshot of fb that is safe to use after the lock is released
+func copyFeedback(fb *models.Feedback) *models.Feedback {
+	c := *fb
+	c.Tags = slices.Clone(fb.Tags)
+	c.Attachments = slices.Clone(fb.Attachments)
+	c.StatusHistory = slices.Clone(fb.StatusHistory)
+	c.ModerationReasons = slices.Clone(fb.ModerationReasons)
+	c.Replies = slices.Clone(fb.Replies)
+	return &c
+}
+
+// copyFeedbackList returns snapshots of every entry in list
+func copyFeedbackList(list []*models.Feedback) []*models.Feedback {
+	result := make([]*models.Feedback, len(list))
+	for i, fb := range list {
+		result[i] = copyFeedback(fb)
 	}
+	return result
+}
+
+// feedbackBefore orders feedback by creation time, breaking ties by ID
+func feedbackBefore(a, b *models.Feedback) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.ID < b.ID
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// insertSorted inserts fb into an oldest-first slice
+func insertSorted(list []*models.Feedback, fb *models.Feedback) []*models.Feedback {
+	i := sort.Search(len(list), func(i int) bool { return feedbackBefore(fb, list[i]) })
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = fb
+	return list
 }
 
-// StoreFeedback stores user feedback
-func (s *FeedbackService) StoreFeedback(userID, email, content, platform string) (*models.Feedback, error) {
+// StoreFeedback moderates and stores user feedback. Feedback rejected by a check is not
+// stored and the check's error is returned. Feedback flagged by a check is stored as held.
+func (s *FeedbackService) StoreFeedback(userID, email string, req models.SubmitFeedbackRequest) (*models.Feedback, error) {
+	if err := ValidateFeedbackSubmission(&req); err != nil {
+		return nil, err
+	}
+
 	feedback := &models.Feedback{
 		ID:        uuid.New().String(),
 		UserID:    userID,
 		Email:     email,
-		Content:   content,
-		Platform:  platform,
+		Content:   req.Content,
+		Platform:  req.Platform,
+		Rating:    req.Rating,
+		Category:  req.Category,
+		Screen:    req.Screen,
+		Tags:      req.Tags,
+		Status:    models.FeedbackStatusNew,
 		CreatedAt: time.Now(),
 	}
 
+	// Checks run under the lock so concurrent duplicates can't both pass
 	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.moderate(feedback, false); err != nil {
+		return nil, err
+	}
+
 	s.feedback[feedback.ID] = feedback
+	s.all = insertSorted(s.all, feedback)
+	s.byUser[userID] = insertSorted(s.byUser[userID], feedback)
+	s.index.Add(feedback)
+
+	if feedback.ModerationStatus == models.ModerationApproved {
+		s.publishLocked(feedback)
+	}
+	return copyFeedback(feedback), nil
+}
+
+// publishLocked records that approved feedback was published and announces it. Must be
+// called with the lock held.
+func (s *FeedbackService) publishLocked(fb *models.Feedback) {
+	now := time.Now()
+	fb.PublishedAt = &now
+	s.events.Publish(EventFeedbackCreated, copyFeedback(fb))
+}
+
+// moderate runs the moderation checks on feedback and sets its moderation state. For
+// edits, checks that only apply to new submissions are skipped. Must be called with the
+// lock held.
+func (s *FeedbackService) moderate(fb *models.Feedback, edit bool) error {
+	fb.ModerationStatus = models.ModerationApproved
+	fb.ModerationReasons = nil
+	for _, check := range s.checks {
+		if _, submissionOnly := check.(SubmissionCheck); submissionOnly && edit {
+			continue
+		}
+		result := check.Check(fb, s.recentLocked)
+		switch result.Action {
+		case ModerationReject:
+			return result.Err
+		case ModerationHold:
+			fb.ModerationStatus = models.ModerationHeld
+			fb.ModerationReasons = append(fb.ModerationReasons, result.Reason)
+		}
+	}
+	return nil
+}
+
+// recentLocked returns feedback created at or after since, newest first. Must be called with the lock held.
+func (s *FeedbackService) recentLocked(since time.Time) []*models.Feedback {
+	var result []*models.Feedback
+	for i := len(s.all) - 1; i >= 0 && !s.all[i].CreatedAt.Before(since); i-- {
+		result = append(result, s.all[i])
+	}
+	return result
+}
+
+// ReviewHeldFeedback approves or rejects feedback held by moderation. published reports
+// whether approval published the feedback for the first time; feedback that was held
+// after an edit has already been published.
+func (s *FeedbackService) ReviewHeldFeedback(feedbackID string, approve bool) (fb *models.Feedback, published bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fb, exists := s.feedback[feedbackID]
+	if !exists {
+		return nil, false, ErrFeedbackNotFound
+	}
+	if fb.ModerationStatus != models.ModerationHeld {
+		return nil, false, ErrFeedbackNotHeld
+	}
+
+	fb.ModerationStatus = models.ModerationRejected
+	if approve {
+		fb.ModerationStatus = models.ModerationApproved
+		if fb.PublishedAt == nil {
+			s.publishLocked(fb)
+			published = true
+		}
+	}
+	return copyFeedback(fb), published, nil
+}
+
+// SetTicketURL records the tracker issue created for feedback
+func (s *FeedbackService) SetTicketURL(feedbackID, url string) (*models.Feedback, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fb, exists := s.feedback[feedbackID]
+	if !exists {
+		return nil, ErrFeedbackNotFound
+	}
+	fb.TicketURL = url
+	return copyFeedback(fb), nil
+}
+
+// ownedEditable returns feedback the user may still edit or delete. Must be called with the lock held.
+func (s *FeedbackService) ownedEditable(userID, feedbackID string) (*models.Feedback, error) {
+	fb, exists := s.feedback[feedbackID]
+	if !exists {
+		return nil, ErrFeedbackNotFound
+	}
+	if fb.UserID != userID {
+		return nil, ErrFeedbackNotOwnedByUser
+	}
+	if time.Since(fb.CreatedAt) > s.editWindow {
+		return nil, ErrEditWindowClosed
+	}
+	return fb, nil
+}
+
+// UpdateFeedback edits the user's own feedback while it is within the edit window.
+// Edited text goes through the moderation checks again: an edit a check rejects is not
+// applied, and approved feedback a check flags is held. Held feedback stays held until
+// reviewed, and rejected feedback stays rejected.
+func (s *FeedbackService) UpdateFeedback(userID, feedbackID string, req models.UpdateFeedbackRequest) (*models.Feedback, error) {
+	if err := ValidateFeedbackUpdate(&req); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fb, err := s.ownedEditable(userID, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := copyFeedback(fb)
+	if req.Content != nil {
+		edited.Content = *req.Content
+	}
+	if req.Rating != nil {
+		edited.Rating = *req.Rating
+	}
+	if req.Category != nil {
+		edited.Category = *req.Category
+	}
+	if req.Screen != nil {
+		edited.Screen = *req.Screen
+	}
+	if req.Tags != nil {
+		edited.Tags = *req.Tags
+	}
+
+	textChanged := req.Content != nil || req.Screen != nil || req.Tags != nil
+	if textChanged && fb.ModerationStatus != models.ModerationRejected {
+		if err := s.moderate(edited, true); err != nil {
+			return nil, err
+		}
+		if fb.ModerationStatus == models.ModerationHeld && edited.ModerationStatus == models.ModerationApproved {
+			edited.ModerationStatus = models.ModerationHeld
+			edited.ModerationReasons = fb.ModerationReasons
+		}
+	}
+
+	now := time.Now()
+	edited.EditedAt = &now
+	*fb = *edited
+	if req.Content != nil {
+		s.index.Add(fb)
+	}
+
+	return copyFeedback(fb), nil
+}
+
+// DeleteFeedback removes the user's own feedback while it is within the edit window
+// and returns the removed entry
+func (s *FeedbackService) DeleteFeedback(userID, feedbackID string) (*models.Feedback, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fb, err := s.ownedEditable(userID, feedbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.feedback, fb.ID)
+	s.index.Remove(fb.ID)
+	s.all = removeFeedback(s.all, fb)
+	s.byUser[userID] = removeFeedback(s.byUser[userID], fb)
+	if len(s.byUser[userID]) == 0 {
+		delete(s.byUser, userID)
+	}
+
+	return fb, nil
+}
+
+// removeFeedback removes fb from an oldest-first slice
+func removeFeedback(list []*models.Feedback, fb *models.Feedback) []*models.Feedback {
+	i := sort.Search(len(list), func(i int) bool { return !feedbackBefore(list[i], fb) })
+	if i < len(list) && list[i] == fb {
+		return slices.Delete(list, i, i+1)
+	}
+	return list
+}
+
+// UpdateStatus moves feedback to a new status and records the transition
+func (s *FeedbackService) UpdateStatus(feedbackID, changedBy, status, note string) (*models.Feedback, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fb, exists := s.feedback[feedbackID]
+	if !exists {
+		return nil, ErrFeedbackNotFound
+	}
+	if !slices.Contains(feedbackTransitions[fb.Status], status) {
+		return nil, ErrInvalidTransition
+	}
+
+	fb.StatusHistory = append(fb.StatusHistory, &models.FeedbackStatusChange{
+		From:      fb.Status,
+		To:        status,
+		ChangedBy: changedBy,
+		Note:      strings.TrimSpace(note),
+		ChangedAt: time.Now(),
+	})
+	fb.Status = status
+
+	return copyFeedback(fb), nil
+}
+
+// AddReply adds a reply to a feedback thread. Users can only reply to their own feedback.
+// The feedback's author is emailed when someone else replies.
+func (s *FeedbackService) AddReply(ctx context.Context, feedbackID, authorID, authorRole string, req models.FeedbackReplyRequest) (*models.FeedbackReply, error) {
+	if err := ValidateFeedbackReply(&req); err != nil {
+		return nil, err
+	}
+
+	reply := &models.FeedbackReply{
+		ID:         uuid.New().String(),
+		FeedbackID: feedbackID,
+		ParentID:   req.ParentID,
+		AuthorID:   authorID,
+		AuthorRole: authorRole,
+		Content:    req.Content,
+		CreatedAt:  time.Now(),
+	}
+
+	s.mu.Lock()
+	fb, exists := s.feedback[feedbackID]
+	if !exists {
+		s.mu.Unlock()
+		return nil, ErrFeedbackNotFound
+	}
+	if authorRole == models.RoleUser && fb.UserID != authorID {
+		s.mu.Unlock()
+		return nil, ErrFeedbackNotOwnedByUser
+	}
+	if reply.ParentID != "" && !slices.ContainsFunc(fb.Replies, func(r *models.FeedbackReply) bool { return r.ID == reply.ParentID }) {
+		s.mu.Unlock()
+		return nil, ErrReplyNotFound
+	}
+	fb.Replies = append(fb.Replies, reply)
+	ownerID, feedbackContent := fb.UserID, fb.Content
 	s.mu.Unlock()
 
-	return feedback, nil
+	if ownerID != authorID {
+		if owner, exists := s.userService.GetUserByID(ownerID); exists {
+			if err := s.emailService.SendFeedbackReply(ctx, owner.Email, feedbackContent, reply.Content); err != nil {
+				s.logger.WarnContext(ctx, "failed to send reply notification", "feedback_id", feedbackID, "to", owner.Email, "error", err)
+			}
+		}
+	}
+
+	return reply, nil
 }
 
-// GetFeedbackByUser returns all feedback for a user
+// normalizeTags lowercases and trims tags, dropping empty and duplicate ones
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// GetFeedbackByUser returns all feedback for a user, oldest first
 func (s *FeedbackService) GetFeedbackByUser(userID string) []*models.Feedback {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var result []*models.Feedback
-	for _, fb := range s.feedback {
-		if fb.UserID == userID {
-			result = append(result, fb)
-		}
+	return copyFeedbackList(s.byUser[userID])
+}
+
+// GetFeedback returns a feedback entry by ID
+func (s *FeedbackService) GetFeedback(feedbackID string) (*models.Feedback, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fb, exists := s.feedback[feedbackID]
+	if !exists {
+		return nil, false
 	}
-	return result
+	return copyFeedback(fb), true
 }
 
-// GetAllFeedback returns all feedback
+// AddAttachment records an uploaded attachment on its feedback entry
+func (s *FeedbackService) AddAttachment(attachment *models.Attachment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fb, exists := s.feedback[attachment.FeedbackID]
+	if !exists {
+		return ErrFeedbackNotFound
+	}
+	if len(fb.Attachments) >= MaxAttachmentsPerFeedback {
+		return ErrTooManyAttachments
+	}
+	fb.Attachments = append(fb.Attachments, attachment)
+	return nil
+}
+
+// GetAllFeedback returns all feedback, oldest first
 func (s *FeedbackService) GetAllFeedback() []*models.Feedback {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	result := make([]*models.Feedback, 0, len(s.feedback))
-	for _, fb := range s.feedback {
-		result = append(result, fb)
+	return copyFeedbackList(s.all)
+}
+
+// GetFeedbackBetween returns feedback created in [from, to), oldest first
+func (s *FeedbackService) GetFeedbackBetween(from, to time.Time) []*models.Feedback {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := sort.Search(len(s.all), func(i int) bool { return !s.all[i].CreatedAt.Before(from) })
+	end := sort.Search(len(s.all), func(i int) bool { return !s.all[i].CreatedAt.Before(to) })
+	if end < start {
+		end = start
 	}
-	return result
+	return copyFeedbackList(s.all[start:end])
+}
+
+// ListFeedback returns a page of feedback matching the query, newest first
+func (s *FeedbackService) ListFeedback(q FeedbackQuery) (*FeedbackPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultFeedbackPageSize
+	}
+	if q.Limit > MaxFeedbackPageSize {
+		q.Limit = MaxFeedbackPageSize
+	}
+
+	var after *models.Feedback
+	if q.Cursor != "" {
+		var err error
+		if after, err = decodeFeedbackCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.all
+	if q.UserID != "" {
+		list = s.byUser[q.UserID]
+	}
+
+	// Walk backwards from the newest entry older than the cursor
+	end := len(list)
+	if after != nil {
+		end = sort.Search(len(list), func(i int) bool { return !feedbackBefore(list[i], after) })
+	}
+
+	page := &FeedbackPage{Feedback: make([]*models.Feedback, 0, q.Limit)}
+	for i := end - 1; i >= 0; i-- {
+		fb := list[i]
+		if !q.From.IsZero() && fb.CreatedAt.Before(q.From) {
+			break // everything further back is older still
+		}
+		if !q.To.IsZero() && !fb.CreatedAt.Before(q.To) {
+			continue
+		}
+		if !q.matches(fb) {
+			continue
+		}
+
+		if len(page.Feedback) == q.Limit {
+			page.NextCursor = encodeFeedbackCursor(page.Feedback[len(page.Feedback)-1])
+			break
+		}
+		page.Feedback = append(page.Feedback, copyFeedback(fb))
+	}
+
+	return page, nil
+}
+
+// encodeFeedbackCursor returns an opaque cursor pointing just past fb
+func encodeFeedbackCursor(fb *models.Feedback) string {
+	raw := fmt.Sprintf("%d:%s", fb.CreatedAt.UnixNano(), fb.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeFeedbackCursor returns the position a cursor points past
+func decodeFeedbackCursor(cursor string) (*models.Feedback, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &models.Feedback{ID: id, CreatedAt: time.Unix(0, n)}, nil
+}
+
+// DeleteFeedbackByUser removes all feedback for a user and returns how many entries were removed
+func (s *FeedbackService) DeleteFeedbackByUser(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := len(s.byUser[userID])
+	if removed == 0 {
+		return 0
+	}
+
+	for _, fb := range s.byUser[userID] {
+		delete(s.feedback, fb.ID)
+		s.index.Remove(fb.ID)
+	}
+	delete(s.byUser, userID)
+
+	kept := s.all[:0]
+	for _, fb := range s.all {
+		if fb.UserID != userID {
+			kept = append(kept, fb)
+		}
+	}
+	for i := len(kept); i < len(s.all); i++ {
+		s.all[i] = nil
+	}
+	s.all = kept
+
+	return removed
+}
+
+// DeleteModerationState drops any per-user state kept by the moderation checks
+func (s *FeedbackService) DeleteModerationState(userID string) {
+	for _, check := range s.checks {
+		if c, ok := check.(UserStateCheck); ok {
+			c.DeleteUser(userID)
+		}
+	}
+}
+
+// FeedbackSearchResult is a feedback entry matching a search, with a highlighted excerpt
+type FeedbackSearchResult struct {
+	Feedback *models.Feedback `json:"feedback"`
+	Score    float64          `json:"score"`
+	Snippet  string           `json:"snippet"`
+}
+
+// SearchFeedback runs a full-text search over feedback content. Plain words must all
+// appear (in any form, e.g. "crash" matches "crashing"); "quoted phrases" must appear in order.
+func (s *FeedbackService) SearchFeedback(query string, limit int) ([]*FeedbackSearchResult, error) {
+	if limit <= 0 {
+		limit = DefaultFeedbackPageSize
+	}
+	if limit > MaxFeedbackPageSize {
+		limit = MaxFeedbackPageSize
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits, err := s.index.Search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*FeedbackSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		if fb, exists := s.feedback[hit.FeedbackID]; exists {
+			results = append(results, &FeedbackSearchResult{
+				Feedback: copyFeedback(fb),
+				Score:    hit.Score,
+				Snippet:  hit.Snippet,
+			})
+		}
+	}
+	return results, nil
 }
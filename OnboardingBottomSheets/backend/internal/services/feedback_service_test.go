@@ -0,0 +1,385 @@
+package services
+
+import (
+	"encoding/base64"
+	"slices"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+
+	"golang.org/x/time/rate"
+)
+
+func TestUpdateFeedbackHoldsContentThatFailsModeration(t *testing.T) {
+	cfg := config.Default().Feedback
+	cfg.BlockedWords = []string{"spam"}
+	cfg.MaxLinks = 1
+	s, events := newTestFeedbackService(DefaultModerationChecks(cfg)...)
+
+	fb, err := s.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "Nice app", Platform: "ios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fb.ModerationStatus != models.ModerationApproved || fb.PublishedAt == nil {
+		t.Fatalf("new feedback = %s (published %v), want approved and published", fb.ModerationStatus, fb.PublishedAt)
+	}
+
+	edited, err := s.UpdateFeedback("user-1", fb.ID, models.UpdateFeedbackRequest{Content: strPtr("Buy spam at https://a.example and https://b.example")})
+	if err != nil {
+		t.Fatalf("UpdateFeedback: %v", err)
+	}
+	if edited.ModerationStatus != models.ModerationHeld || len(edited.ModerationReasons) != 2 {
+		t.Fatalf("edited feedback = %s %v, want held for a blocked word and links", edited.ModerationStatus, edited.ModerationReasons)
+	}
+
+	held, err := s.ListFeedback(FeedbackQuery{Moderation: models.ModerationHeld})
+	if err != nil || len(held.Feedback) != 1 {
+		t.Fatalf("held listing = %v, %v; want the edited feedback", held, err)
+	}
+	results, err := s.SearchFeedback("spam", 10)
+	if err != nil || len(results) != 1 || results[0].Feedback.ModerationStatus != models.ModerationHeld {
+		t.Fatalf("search = %v, %v; want the held feedback", results, err)
+	}
+	if results, _ := s.SearchFeedback("nice", 10); len(results) != 0 {
+		t.Errorf("search still finds the old content")
+	}
+
+	approved, published, err := s.ReviewHeldFeedback(fb.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.ModerationStatus != models.ModerationApproved || published {
+		t.Errorf("approval = %s, published %v; want approved without publishing again", approved.ModerationStatus, published)
+	}
+	if n := events.count(); n != 1 {
+		t.Errorf("%d feedback events published, want 1", n)
+	}
+}
+
+func TestUpdateFeedbackKeepsHeldAndRejectedStatus(t *testing.T) {
+	s, _ := newTestFeedbackService(NewBlockedWordsCheck([]string{"spam"}))
+
+	held, err := s.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "spam here", Platform: "ios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err := s.UpdateFeedback("user-1", held.ID, models.UpdateFeedbackRequest{Content: strPtr("Clean now")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.ModerationStatus != models.ModerationHeld {
+		t.Errorf("held feedback became %s after a clean edit, want held until reviewed", edited.ModerationStatus)
+	}
+
+	if _, _, err := s.ReviewHeldFeedback(held.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	edited, err = s.UpdateFeedback("user-1", held.ID, models.UpdateFeedbackRequest{Content: strPtr("Please reconsider")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.ModerationStatus != models.ModerationRejected {
+		t.Errorf("rejected feedback became %s after an edit", edited.ModerationStatus)
+	}
+}
+
+func TestUpdateFeedbackSkipsSubmissionChecks(t *testing.T) {
+	s, _ := newTestFeedbackService(NewRateLimitCheck(rate.Every(time.Hour), 1), &DuplicateCheck{Window: time.Hour})
+
+	fb, err := s.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "First version", Platform: "ios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err := s.UpdateFeedback("user-1", fb.ID, models.UpdateFeedbackRequest{Screen: strPtr("Settings")})
+	if err != nil {
+		t.Fatalf("edit was rate limited: %v", err)
+	}
+	if edited.ModerationStatus != models.ModerationApproved {
+		t.Errorf("edit held as %v, want approved; feedback is not a duplicate of itself", edited.ModerationReasons)
+	}
+}
+
+func TestDuplicateCheckOnlyComparesTheSameUser(t *testing.T) {
+	s, _ := newTestFeedbackService(&DuplicateCheck{Window: time.Hour})
+
+	submit := func(userID, content string) *models.Feedback {
+		t.Helper()
+		fb, err := s.StoreFeedback(userID, userID+"@example.com", models.SubmitFeedbackRequest{Content: content, Platform: "ios"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fb
+	}
+
+	first := submit("user-1", "Love it!")
+	if other := submit("user-2", "love   it!"); other.ModerationStatus != models.ModerationApproved {
+		t.Errorf("another user's matching feedback held as %v, want approved", other.ModerationReasons)
+	}
+
+	repeat := submit("user-1", "LOVE it!")
+	if repeat.ModerationStatus != models.ModerationHeld {
+		t.Fatalf("repeat from the same user is %s, want held", repeat.ModerationStatus)
+	}
+	if want := "duplicate of feedback " + first.ID; len(repeat.ModerationReasons) != 1 || repeat.ModerationReasons[0] != want {
+		t.Errorf("reasons = %v, want [%s]", repeat.ModerationReasons, want)
+	}
+}
+
+func TestDefaultModerationChecksUseConfiguredRateLimit(t *testing.T) {
+	cfg := config.Default().Feedback
+	cfg.RateLimitBurst = 2
+	cfg.RateLimitInterval = time.Hour
+	s, _ := newConfiguredFeedbackService(cfg, DefaultModerationChecks(cfg)...)
+
+	for i, content := range []string{"First", "Second", "Third"} {
+		_, err := s.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: content, Platform: "ios"})
+		if i < 2 && err != nil {
+			t.Fatalf("submission %d: %v", i+1, err)
+		}
+		if i == 2 && err != ErrFeedbackRateLimited {
+			t.Fatalf("submission 3 = %v, want %v", err, ErrFeedbackRateLimited)
+		}
+	}
+}
+
+func TestUpdateFeedbackHonoursConfiguredEditWindow(t *testing.T) {
+	cfg := config.Default().Feedback
+	cfg.EditWindow = time.Millisecond
+	s, _ := newConfiguredFeedbackService(cfg)
+
+	fb, err := s.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "Typo", Platform: "ios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.UpdateFeedback("user-1", fb.ID, models.UpdateFeedbackRequest{Content: strPtr("Fixed")}); err != ErrEditWindowClosed {
+		t.Fatalf("edit after the window = %v, want %v", err, ErrEditWindowClosed)
+	}
+	if _, err := s.DeleteFeedback("user-1", fb.ID); err != ErrEditWindowClosed {
+		t.Fatalf("delete after the window = %v, want %v", err, ErrEditWindowClosed)
+	}
+}
+
+// listAll follows cursors through every page of q and returns the IDs in order
+func listAll(t *testing.T, s *FeedbackService, q FeedbackQuery) []string {
+	t.Helper()
+	var ids []string
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("listing never reached the last page")
+		}
+		page, err := s.ListFeedback(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fb := range page.Feedback {
+			ids = append(ids, fb.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestListFeedbackPagesThroughTiedTimestamps(t *testing.T) {
+	s, _ := newTestFeedbackService()
+	tied := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	seedFeedback(s,
+		&models.Feedback{ID: "a", CreatedAt: tied.Add(-time.Minute)},
+		&models.Feedback{ID: "e", CreatedAt: tied},
+		&models.Feedback{ID: "b", CreatedAt: tied},
+		&models.Feedback{ID: "d", CreatedAt: tied},
+		&models.Feedback{ID: "c", CreatedAt: tied},
+		&models.Feedback{ID: "f", CreatedAt: tied},
+		&models.Feedback{ID: "g", CreatedAt: tied.Add(time.Minute)},
+	)
+
+	// Newest first, with tied entries in descending ID order
+	want := []string{"g", "f", "e", "d", "c", "b", "a"}
+	for _, limit := range []int{1, 2, 3, 7} {
+		got := listAll(t, s, FeedbackQuery{Limit: limit})
+		if !slices.Equal(got, want) {
+			t.Errorf("limit %d: listed %v, want %v", limit, got, want)
+		}
+	}
+}
+
+func TestListFeedbackRejectsMalformedCursors(t *testing.T) {
+	s, _ := newTestFeedbackService()
+	seedFeedback(s, &models.Feedback{CreatedAt: time.Now()})
+
+	for _, cursor := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("no separator")),
+		base64.RawURLEncoding.EncodeToString([]byte("yesterday:fb-000")),
+	} {
+		if _, err := s.ListFeedback(FeedbackQuery{Cursor: cursor}); err != ErrInvalidCursor {
+			t.Errorf("cursor %q: err = %v, want %v", cursor, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestListFeedbackClampsLimit(t *testing.T) {
+	s, _ := newTestFeedbackService()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxFeedbackPageSize+10; i++ {
+		seedFeedback(s, &models.Feedback{CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{0, DefaultFeedbackPageSize},
+		{-5, DefaultFeedbackPageSize},
+		{MaxFeedbackPageSize + 1, MaxFeedbackPageSize},
+		{1000, MaxFeedbackPageSize},
+	}
+	for _, tt := range tests {
+		page, err := s.ListFeedback(FeedbackQuery{Limit: tt.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Feedback) != tt.want {
+			t.Errorf("limit %d returned %d entries, want %d", tt.limit, len(page.Feedback), tt.want)
+		}
+		if page.NextCursor == "" {
+			t.Errorf("limit %d: missing next cursor with more entries left", tt.limit)
+		}
+	}
+}
+
+func TestListFeedbackFiltersByCreationTime(t *testing.T) {
+	s, _ := newTestFeedbackService()
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	seedFeedback(s,
+		&models.Feedback{ID: "feb-29", CreatedAt: day(1).Add(-time.Nanosecond)},
+		&models.Feedback{ID: "mar-1", CreatedAt: day(1)},
+		&models.Feedback{ID: "mar-2", CreatedAt: day(2)},
+		&models.Feedback{ID: "mar-3-late", CreatedAt: day(4).Add(-time.Nanosecond)},
+		&models.Feedback{ID: "mar-4", CreatedAt: day(4)},
+	)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"from is inclusive", day(2), time.Time{}, []string{"mar-4", "mar-3-late", "mar-2"}},
+		{"to is exclusive", time.Time{}, day(2), []string{"mar-1", "feb-29"}},
+		{"both", day(1), day(4), []string{"mar-3-late", "mar-2", "mar-1"}},
+		{"empty range", day(3), day(3), nil},
+	}
+	for _, tt := range tests {
+		got := listAll(t, s, FeedbackQuery{From: tt.from, To: tt.to, Limit: 2})
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: listed %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateStatusFollowsTheTransitionRules(t *testing.T) {
+	statuses := []string{
+		models.FeedbackStatusNew, models.FeedbackStatusTriaged, models.FeedbackStatusInProgress,
+		models.FeedbackStatusResolved, models.FeedbackStatusWontFix,
+	}
+	allowed := map[string][]string{
+		models.FeedbackStatusNew:        {models.FeedbackStatusTriaged, models.FeedbackStatusInProgress, models.FeedbackStatusResolved, models.FeedbackStatusWontFix},
+		models.FeedbackStatusTriaged:    {models.FeedbackStatusInProgress, models.FeedbackStatusResolved, models.FeedbackStatusWontFix},
+		models.FeedbackStatusInProgress: {models.FeedbackStatusTriaged, models.FeedbackStatusResolved, models.FeedbackStatusWontFix},
+		models.FeedbackStatusResolved:   {models.FeedbackStatusTriaged},
+		models.FeedbackStatusWontFix:    {models.FeedbackStatusTriaged},
+	}
+
+	s, _ := newTestFeedbackService()
+	for _, from := range statuses {
+		for _, to := range append(slices.Clone(statuses), "closed", "") {
+			fb := &models.Feedback{Status: from, CreatedAt: time.Now()}
+			seedFeedback(s, fb)
+
+			updated, err := s.UpdateStatus(fb.ID, "admin-1", to, "  checked  ")
+			if !slices.Contains(allowed[from], to) {
+				if err != ErrInvalidTransition {
+					t.Errorf("%s -> %q: err = %v, want %v", from, to, err, ErrInvalidTransition)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s -> %s: unexpected error %v", from, to, err)
+				continue
+			}
+			if updated.Status != to || len(updated.StatusHistory) != 1 {
+				t.Errorf("%s -> %s: status %s with %d history entries", from, to, updated.Status, len(updated.StatusHistory))
+				continue
+			}
+			if change := updated.StatusHistory[0]; change.From != from || change.To != to || change.ChangedBy != "admin-1" || change.Note != "checked" {
+				t.Errorf("%s -> %s: recorded %+v", from, to, change)
+			}
+		}
+	}
+
+	if _, err := s.UpdateStatus("missing", "admin-1", models.FeedbackStatusTriaged, ""); err != ErrFeedbackNotFound {
+		t.Errorf("missing feedback: err = %v, want %v", err, ErrFeedbackNotFound)
+	}
+}
+
+func TestReviewHeldFeedback(t *testing.T) {
+	s, events := newTestFeedbackService(NewBlockedWordsCheck([]string{"spam"}))
+	submit := func(content string) *models.Feedback {
+		t.Helper()
+		fb, err := s.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: content, Platform: "ios"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fb
+	}
+
+	// Approving held feedback publishes it for the first time
+	held := submit("spam but real")
+	approved, published, err := s.ReviewHeldFeedback(held.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !published || approved.ModerationStatus != models.ModerationApproved || approved.PublishedAt == nil || events.count() != 1 {
+		t.Errorf("approve: published %v, status %s, %d events, want a first publication", published, approved.ModerationStatus, events.count())
+	}
+
+	// Rejecting keeps it unpublished
+	held = submit("more spam")
+	rejected, published, err := s.ReviewHeldFeedback(held.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published || rejected.ModerationStatus != models.ModerationRejected || rejected.PublishedAt != nil || events.count() != 1 {
+		t.Errorf("reject: published %v, status %s, %d events, want it left unpublished", published, rejected.ModerationStatus, events.count())
+	}
+
+	// Feedback held after an edit was already published, so approval doesn't announce it again
+	clean := submit("Clean feedback")
+	if _, err := s.UpdateFeedback("user-1", clean.ID, models.UpdateFeedbackRequest{Content: strPtr("Now with spam")}); err != nil {
+		t.Fatal(err)
+	}
+	approved, published, err = s.ReviewHeldFeedback(clean.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published || approved.ModerationStatus != models.ModerationApproved || events.count() != 2 {
+		t.Errorf("approve after edit: published %v, status %s, %d events, want no second publication", published, approved.ModerationStatus, events.count())
+	}
+
+	for _, tt := range []struct {
+		id   string
+		want error
+	}{
+		{rejected.ID, ErrFeedbackNotHeld},
+		{approved.ID, ErrFeedbackNotHeld},
+		{"missing", ErrFeedbackNotFound},
+	} {
+		if _, _, err := s.ReviewHeldFeedback(tt.id, true); err != tt.want {
+			t.Errorf("review %s: err = %v, want %v", tt.id, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"onboarding-backend/internal/models"
+)
+
+const (
+	// MinFeedbackLength is the fewest characters accepted in feedback content, after trimming
+	MinFeedbackLength = 3
+
+	// MaxFeedbackLength is the most characters accepted in feedback content, after trimming
+	MaxFeedbackLength = 5000
+
+	// MaxReplyLength is the most characters accepted in a reply, after trimming
+	MaxReplyLength = 5000
+)
+
+// knownPlatforms are the client platforms a submission may name
+var knownPlatforms = map[string]bool{
+	models.PlatformIOS:     true,
+	models.PlatformAndroid: true,
+	models.PlatformWeb:     true,
+}
+
+// validatePlatform lowercases a platform and checks it's a known one. A missing
+// platform is recorded as unknown.
+func validatePlatform(verr *ValidationError, platform string) string {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	switch {
+	case platform == "":
+		return models.PlatformUnknown
+	case !knownPlatforms[platform]:
+		verr.add("platform", "must be one of ios, android or web")
+	}
+	return platform
+}
+
+// ValidationError reports which request fields are invalid and why
+type ValidationError struct {
+	Fields map[string]string // JSON field name -> message
+}
+
+// Error lists the invalid fields
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "invalid fields: " + strings.Join(names, ", ")
+}
+
+// add records a problem with a field, keeping the first message per field
+func (e *ValidationError) add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, exists := e.Fields[field]; !exists {
+		e.Fields[field] = message
+	}
+}
+
+// orNil returns e if any field is invalid
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// cleanText strips control characters (keeping newlines and tabs) and surrounding
+// whitespace. ok is false if text isn't valid UTF-8. The JSON decoder replaces invalid
+// bytes with U+FFFD, so replacement characters are treated as invalid too.
+func cleanText(text string) (cleaned string, ok bool) {
+	if !utf8.ValidString(text) || strings.ContainsRune(text, utf8.RuneError) {
+		return "", false
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(text), true
+}
+
+// cleanLine is cleanText for single-line fields, where newlines and tabs become spaces
+func cleanLine(text string) (string, bool) {
+	text, ok := cleanText(text)
+	return strings.Join(strings.Fields(text), " "), ok
+}
+
+// validateContent cleans feedback or reply text and checks its length
+func validateContent(verr *ValidationError, field, content string, min, max int) string {
+	content, ok := cleanText(content)
+	switch n := utf8.RuneCountInString(content); {
+	case !ok:
+		verr.add(field, "must be valid UTF-8 text")
+	case n == 0:
+		verr.add(field, "is required")
+	case n < min:
+		verr.add(field, fmt.Sprintf("must be at least %d characters", min))
+	case n > max:
+		verr.add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+	return content
+}
+
+// validateScreen cleans a screen name
+func validateScreen(verr *ValidationError, screen string) string {
+	screen, ok := cleanLine(screen)
+	if !ok {
+		verr.add("screen", "must be valid UTF-8 text")
+	}
+	return screen
+}
+
+// validateTags cleans tags, dropping empty and duplicate ones
+func validateTags(verr *ValidationError, tags []string) []string {
+	for i, tag := range tags {
+		cleaned, ok := cleanLine(tag)
+		if !ok {
+			verr.add("tags", "must be valid UTF-8 text")
+		}
+		tags[i] = cleaned
+	}
+	return normalizeTags(tags)
+}
+
+// ValidateFeedbackSubmission normalizes a submission in place and checks it against
+// the feedback rules. It returns a *ValidationError if any field is invalid.
+func ValidateFeedbackSubmission(req *models.SubmitFeedbackRequest) error {
+	verr := &ValidationError{}
+
+	req.Content = validateContent(verr, "content", req.Content, MinFeedbackLength, MaxFeedbackLength)
+
+	req.Platform = validatePlatform(verr, req.Platform)
+	req.Screen = validateScreen(verr, req.Screen)
+	req.Tags = validateTags(verr, req.Tags)
+
+	return verr.orNil()
+}
+
+// ValidateFeedbackUpdate normalizes the fields present in an edit and checks them
+func ValidateFeedbackUpdate(req *models.UpdateFeedbackRequest) error {
+	verr := &ValidationError{}
+
+	if req.Content != nil {
+		content := validateContent(verr, "content", *req.Content, MinFeedbackLength, MaxFeedbackLength)
+		req.Content = &content
+	}
+	if req.Screen != nil {
+		screen := validateScreen(verr, *req.Screen)
+		req.Screen = &screen
+	}
+	if req.Tags != nil {
+		tags := validateTags(verr, *req.Tags)
+		req.Tags = &tags
+	}
+
+	return verr.orNil()
+}
+
+// ValidateFeedbackReply normalizes a reply in place and checks its length
+func ValidateFeedbackReply(req *models.FeedbackReplyRequest) error {
+	verr := &ValidationError{}
+	req.Content = validateContent(verr, "content", req.Content, 1, MaxReplyLength)
+	return verr.orNil()
+}
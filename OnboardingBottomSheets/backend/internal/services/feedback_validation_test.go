@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"onboarding-backend/internal/models"
+)
+
+func TestValidateFeedbackSubmissionNormalizesPlatform(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     string
+	}{
+		{"ios", models.PlatformIOS},
+		{" Android ", models.PlatformAndroid},
+		{"WEB", models.PlatformWeb},
+		{"", models.PlatformUnknown},
+		{"   ", models.PlatformUnknown},
+	}
+
+	for _, tt := range tests {
+		req := &models.SubmitFeedbackRequest{Content: "Works well", Platform: tt.platform}
+		if err := ValidateFeedbackSubmission(req); err != nil {
+			t.Errorf("platform %q: unexpected error %v", tt.platform, err)
+			continue
+		}
+		if req.Platform != tt.want {
+			t.Errorf("platform %q normalized to %q, want %q", tt.platform, req.Platform, tt.want)
+		}
+	}
+}
+
+func TestValidateFeedbackSubmissionRejectsUnknownPlatform(t *testing.T) {
+	for _, platform := range []string{"windows", "iOS 17", "other"} {
+		req := &models.SubmitFeedbackRequest{Content: "Works well", Platform: platform}
+		err := ValidateFeedbackSubmission(req)
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("platform %q: expected a validation error, got %v", platform, err)
+			continue
+		}
+		if _, ok := verr.Fields["platform"]; !ok || len(verr.Fields) != 1 {
+			t.Errorf("platform %q: expected only a platform field error, got %v", platform, verr.Fields)
+		}
+	}
+}
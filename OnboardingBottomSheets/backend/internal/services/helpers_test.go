@@ -0,0 +1,104 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+// testBaseURL is the public URL links point at in tests
+const testBaseURL = "http://localhost:8080"
+
+// newTestLogger returns a logger that discards its output
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// nopEvents is an EventPublisher that drops every event
+type nopEvents struct{}
+
+func (nopEvents) Publish(string, interface{}) {}
+
+// recordingEvents is an EventPublisher that remembers the events it was given
+type recordingEvents struct {
+	events []string
+	mu     sync.Mutex
+}
+
+func (r *recordingEvents) Publish(eventType string, _ interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, eventType)
+}
+
+func (r *recordingEvents) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+// newTestRedactor returns a redactor that removes email addresses
+func newTestRedactor(t *testing.T) *Redactor {
+	t.Helper()
+	redactor, err := NewRedactor("email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redactor
+}
+
+// newTestUsers returns an unconfigured email service, which logs instead of sending,
+// and a user service granting roles, both logging to logger
+func newTestUsers(logger *slog.Logger, roles config.RolesConfig) (*EmailService, *UserService) {
+	emailService := NewEmailService(config.EmailConfig{}, logger)
+	return emailService, NewUserService(emailService, nopEvents{}, roles, config.Default().Accounts, testBaseURL, logger)
+}
+
+func newTestUserService() *UserService {
+	_, userService := newTestUsers(newTestLogger(), config.RolesConfig{})
+	return userService
+}
+
+func newTestFeedbackService(checks ...ModerationCheck) (*FeedbackService, *recordingEvents) {
+	return newConfiguredFeedbackService(config.Default().Feedback, checks...)
+}
+
+func newConfiguredFeedbackService(cfg config.FeedbackConfig, checks ...ModerationCheck) (*FeedbackService, *recordingEvents) {
+	logger := newTestLogger()
+	events := &recordingEvents{}
+	emailService, userService := newTestUsers(logger, config.RolesConfig{})
+	return NewFeedbackService(userService, emailService, events, cfg, logger, checks...), events
+}
+
+// seedFeedback stores entries directly, bypassing validation and moderation, so tests
+// can choose CreatedAt. Missing IDs, owners, statuses and moderation states are filled in.
+func seedFeedback(s *FeedbackService, entries ...*models.Feedback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fb := range entries {
+		if fb.ID == "" {
+			fb.ID = fmt.Sprintf("fb-%03d", len(s.all))
+		}
+		if fb.UserID == "" {
+			fb.UserID = "user-1"
+		}
+		if fb.Status == "" {
+			fb.Status = models.FeedbackStatusNew
+		}
+		if fb.ModerationStatus == "" {
+			fb.ModerationStatus = models.ModerationApproved
+		}
+		s.feedback[fb.ID] = fb
+		s.all = insertSorted(s.all, fb)
+		s.byUser[fb.UserID] = insertSorted(s.byUser[fb.UserID], fb)
+		s.index.Add(fb)
+	}
+}
+
+func strPtr(s string) *string { return &s }
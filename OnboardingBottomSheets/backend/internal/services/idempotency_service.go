@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"onboarding-backend/internal/config"
+)
+
+var (
+	ErrIdempotencyKeyInUse    = errors.New("a request with this idempotency key is still in progress")
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was used with a different request")
+)
+
+// idempotencySweepInterval is how often expired keys are pruned
+const idempotencySweepInterval = time.Minute
+
+// IdempotentResponse is a stored response replayed for a repeated request
+type IdempotentResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// idempotencyEntry tracks one key. response is nil while the first request is in progress.
+type idempotencyEntry struct {
+	userID      string
+	requestHash string
+	response    *IdempotentResponse
+	expiresAt   time.Time
+}
+
+// IdempotencyService remembers responses by idempotency key so retried requests
+// return the original response instead of running again
+type IdempotencyService struct {
+	entries   map[string]*idempotencyEntry // scoped key -> entry
+	ttl       time.Duration
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewIdempotencyService creates a new idempotency service that replays completed
+// requests for the configured key TTL
+func NewIdempotencyService(cfg config.IdempotencyConfig) *IdempotencyService {
+	return &IdempotencyService{
+		entries: make(map[string]*idempotencyEntry),
+		ttl:     cfg.KeyTTL,
+	}
+}
+
+// Begin claims a key for a user's request. If the key already completed with the same
+// request its stored response is returned. Otherwise the caller owns the key and must
+// call Complete or Release when done.
+func (s *IdempotencyService) Begin(userID, key, requestHash string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if entry, exists := s.entries[key]; exists && now.Before(entry.expiresAt) {
+		if entry.requestHash != requestHash {
+			return nil, ErrIdempotencyKeyMismatch
+		}
+		if entry.response == nil {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return entry.response, nil
+	}
+
+	s.entries[key] = &idempotencyEntry{
+		userID:      userID,
+		requestHash: requestHash,
+		expiresAt:   now.Add(s.ttl),
+	}
+	return nil, nil
+}
+
+// Complete stores the response for a claimed key so it can be replayed
+func (s *IdempotencyService) Complete(key string, response *IdempotentResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[key]; exists {
+		entry.response = response
+		entry.expiresAt = time.Now().Add(s.ttl)
+	}
+}
+
+// Release gives up a claimed key without storing a response, so the request can be retried
+func (s *IdempotencyService) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, exists := s.entries[key]; exists && entry.response == nil {
+		delete(s.entries, key)
+	}
+}
+
+// DeleteByUser removes every key and stored response belonging to a user
+func (s *IdempotencyService) DeleteByUser(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, entry := range s.entries {
+		if entry.userID == userID {
+			delete(s.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// sweep removes expired keys. Must be called with the lock held.
+func (s *IdempotencyService) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
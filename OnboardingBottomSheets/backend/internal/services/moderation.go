@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	ErrFeedbackRateLimited = errors.New("too much feedback submitted")
+	ErrFeedbackNotHeld     = errors.New("feedback is not held for review")
+)
+
+// ModerationAction is the outcome of a moderation check
+type ModerationAction int
+
+const (
+	// ModerationPass lets the feedback through
+	ModerationPass ModerationAction = iota
+	// ModerationHold stores the feedback but holds it for review instead of publishing it
+	ModerationHold
+	// ModerationReject refuses to store the feedback
+	ModerationReject
+)
+
+// ModerationResult is what a check decided and why
+type ModerationResult struct {
+	Action ModerationAction
+	Reason string // shown to reviewers for held feedback
+	Err    error  // returned to the submitter for rejected feedback
+}
+
+// RecentFeedback returns stored feedback created at or after since, newest first
+type RecentFeedback func(since time.Time) []*models.Feedback
+
+// ModerationCheck inspects new feedback before it is stored
+type ModerationCheck interface {
+	Check(fb *models.Feedback, recent RecentFeedback) ModerationResult
+}
+
+// SubmissionCheck is a ModerationCheck that only applies to new submissions, such as a
+// rate limit. Other checks also run when feedback is edited.
+type SubmissionCheck interface {
+	ModerationCheck
+	SubmissionOnly()
+}
+
+// UserStateCheck is a ModerationCheck that keeps state per user, which is dropped when
+// the user's account is deleted
+type UserStateCheck interface {
+	ModerationCheck
+	DeleteUser(userID string)
+}
+
+// DefaultModerationChecks returns the standard pipeline with the configured rate
+// limit, duplicate window, blocked words and link limit
+func DefaultModerationChecks(cfg config.FeedbackConfig) []ModerationCheck {
+	return []ModerationCheck{
+		NewRateLimitCheck(rate.Every(cfg.RateLimitInterval), cfg.RateLimitBurst),
+		&DuplicateCheck{Window: cfg.DuplicateWindow},
+		NewBlockedWordsCheck(cfg.BlockedWords),
+		&LinkCountCheck{Max: cfg.MaxLinks},
+	}
+}
+
+// RateLimitCheck rejects feedback from users who submit too often
+type RateLimitCheck struct {
+	limit    rate.Limit
+	burst    int
+	limiters map[string]*rate.Limiter // userID -> limiter
+	mu       sync.Mutex
+}
+
+// NewRateLimitCheck allows each user burst submissions, refilling at the given rate
+func NewRateLimitCheck(limit rate.Limit, burst int) *RateLimitCheck {
+	return &RateLimitCheck{
+		limit:    limit,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// Check rejects the feedback if the user is over their limit
+func (c *RateLimitCheck) Check(fb *models.Feedback, _ RecentFeedback) ModerationResult {
+	c.mu.Lock()
+	limiter, exists := c.limiters[fb.UserID]
+	if !exists {
+		limiter = rate.NewLimiter(c.limit, c.burst)
+		c.limiters[fb.UserID] = limiter
+	}
+	c.mu.Unlock()
+
+	if !limiter.Allow() {
+		return ModerationResult{Action: ModerationReject, Err: ErrFeedbackRateLimited}
+	}
+	return ModerationResult{}
+}
+
+// SubmissionOnly marks the rate limit as not applying to edits
+func (c *RateLimitCheck) SubmissionOnly() {}
+
+// DeleteUser forgets a user's limiter
+func (c *RateLimitCheck) DeleteUser(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.limiters, userID)
+}
+
+// DuplicateCheck holds feedback whose content matches feedback the same user submitted
+// within the window
+type DuplicateCheck struct {
+	Window time.Duration
+}
+
+// Check compares the feedback with the user's recent feedback. Different users sending
+// the same short text ("Love it!") aren't duplicates.
+func (c *DuplicateCheck) Check(fb *models.Feedback, recent RecentFeedback) ModerationResult {
+	content := comparableContent(fb.Content)
+	for _, other := range recent(fb.CreatedAt.Add(-c.Window)) {
+		if other.ID != fb.ID && other.UserID == fb.UserID && comparableContent(other.Content) == content {
+			return ModerationResult{
+				Action: ModerationHold,
+				Reason: fmt.Sprintf("duplicate of feedback %s", other.ID),
+			}
+		}
+	}
+	return ModerationResult{}
+}
+
+// comparableContent lowercases text and collapses whitespace for duplicate detection
+func comparableContent(content string) string {
+	return strings.Join(strings.Fields(strings.ToLower(content)), " ")
+}
+
+// BlockedWordsCheck holds feedback containing any blocked word
+type BlockedWordsCheck struct {
+	words map[string]bool
+}
+
+// NewBlockedWordsCheck creates a check for the given words, matched case-insensitively
+func NewBlockedWordsCheck(words []string) *BlockedWordsCheck {
+	c := &BlockedWordsCheck{words: make(map[string]bool)}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			c.words[word] = true
+		}
+	}
+	return c
+}
+
+// Check looks for blocked words in the content, screen and tags
+func (c *BlockedWordsCheck) Check(fb *models.Feedback, _ RecentFeedback) ModerationResult {
+	if len(c.words) == 0 {
+		return ModerationResult{}
+	}
+
+	text := strings.ToLower(strings.Join(append([]string{fb.Content, fb.Screen}, fb.Tags...), " "))
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	for _, word := range words {
+		if c.words[word] {
+			return ModerationResult{Action: ModerationHold, Reason: "contains a blocked word"}
+		}
+	}
+	return ModerationResult{}
+}
+
+// linkPattern matches URLs and www. addresses
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// LinkCountCheck holds feedback containing more than Max links
+type LinkCountCheck struct {
+	Max int
+}
+
+// Check counts the links in the content
+func (c *LinkCountCheck) Check(fb *models.Feedback, _ RecentFeedback) ModerationResult {
+	if n := len(linkPattern.FindAllString(fb.Content, -1)); n > c.Max {
+		return ModerationResult{
+			Action: ModerationHold,
+			Reason: fmt.Sprintf("contains %d links (limit %d)", n, c.Max),
+		}
+	}
+	return ModerationResult{}
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+var (
+	ErrPasskeySessionNotFound = errors.New("passkey session not found or expired")
+	ErrPasskeyVerification    = errors.New("passkey verification failed")
+	ErrPasskeyCloned          = errors.New("passkey sign count indicates a cloned authenticator")
+)
+
+// passkeyCeremonyTimeout bounds how long a begun ceremony may take to finish
+const passkeyCeremonyTimeout = 5 * time.Minute
+
+// passkeySession holds the server-side state of an in-flight WebAuthn ceremony
+type passkeySession struct {
+	userID string // empty for discoverable logins
+	decoy  bool   // login begun for an email without passkeys; never succeeds
+	data   *webauthn.SessionData
+}
+
+// PasskeyService handles WebAuthn passkey registration and login
+type PasskeyService struct {
+	webAuthn    *webauthn.WebAuthn
+	authService *AuthService
+	credentials map[string][]*models.PasskeyCredential // userID -> credentials
+	sessions    map[string]*passkeySession             // sessionID -> ceremony state
+	decoyKey    []byte                                 // derives stable fake credential IDs per email
+	mu          sync.Mutex
+}
+
+// NewPasskeyService creates a new passkey service for the configured relying party
+func NewPasskeyService(authService *AuthService, cfg config.PasskeyConfig) (*PasskeyService, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPName,
+		RPOrigins:     cfg.RPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyCeremonyTimeout},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyCeremonyTimeout},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	decoyKey := make([]byte, 32)
+	if _, err := rand.Read(decoyKey); err != nil {
+		return nil, err
+	}
+
+	return &PasskeyService{
+		webAuthn:    w,
+		authService: authService,
+		credentials: make(map[string][]*models.PasskeyCredential),
+		sessions:    make(map[string]*passkeySession),
+		decoyKey:    decoyKey,
+	}, nil
+}
+
+// BeginRegistration starts a passkey registration ceremony for an authenticated user
+func (s *PasskeyService) BeginRegistration(userID string) (string, *protocol.CredentialCreation, error) {
+	user, exists := s.authService.GetUserByID(userID)
+	if !exists {
+		return "", nil, ErrUserNotFound
+	}
+
+	wu := s.webAuthnUser(user)
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(wu.credentials))
+	for _, cred := range wu.credentials {
+		exclusions = append(exclusions, cred.Descriptor())
+	}
+
+	creation, data, err := s.webAuthn.BeginRegistration(
+		wu,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sessionID, err := s.storeSession(&passkeySession{userID: userID, data: data})
+	if err != nil {
+		return "", nil, err
+	}
+	return sessionID, creation, nil
+}
+
+// FinishRegistration verifies the authenticator's attestation and stores the new credential
+func (s *PasskeyService) FinishRegistration(userID, sessionID string, body io.Reader) (*models.PasskeyCredential, error) {
+	session, err := s.takeSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.userID != userID {
+		return nil, ErrPasskeySessionNotFound
+	}
+
+	user, exists := s.authService.GetUserByID(userID)
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, ErrPasskeyVerification
+	}
+
+	credential, err := s.webAuthn.CreateCredential(s.webAuthnUser(user), *session.data, parsed)
+	if err != nil {
+		return nil, ErrPasskeyVerification
+	}
+
+	stored := &models.PasskeyCredential{
+		ID:              base64.RawURLEncoding.EncodeToString(credential.ID),
+		UserID:          userID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+	for _, t := range credential.Transport {
+		stored.Transports = append(stored.Transports, string(t))
+	}
+
+	s.mu.Lock()
+	s.credentials[userID] = append(s.credentials[userID], stored)
+	s.mu.Unlock()
+
+	return copyPasskeyCredential(stored), nil
+}
+
+// BeginLogin starts a passkey login ceremony. With an email the allowed credentials
+// are limited to that user's passkeys; without one a discoverable login is started.
+// An email without passkeys (or without an account) gets a challenge for a stable fake
+// credential, so the response doesn't reveal which accounts exist.
+func (s *PasskeyService) BeginLogin(email string) (string, *protocol.CredentialAssertion, error) {
+	var (
+		assertion *protocol.CredentialAssertion
+		data      *webauthn.SessionData
+		userID    string
+		decoy     bool
+		err       error
+	)
+
+	if email != "" {
+		var wu *passkeyUser
+		if user, exists := s.authService.GetUserByEmail(email); exists {
+			wu = s.webAuthnUser(user)
+			userID = user.ID
+		}
+		if wu == nil || len(wu.credentials) == 0 {
+			wu, userID, decoy = s.decoyUser(email), "", true
+		}
+		assertion, data, err = s.webAuthn.BeginLogin(wu)
+	} else {
+		assertion, data, err = s.webAuthn.BeginDiscoverableLogin()
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	sessionID, err := s.storeSession(&passkeySession{userID: userID, decoy: decoy, data: data})
+	if err != nil {
+		return "", nil, err
+	}
+	return sessionID, assertion, nil
+}
+
+// decoyUser returns a user with one fake credential whose ID is derived from the
+// email, so repeated requests for the same address look like a real account's
+func (s *PasskeyService) decoyUser(email string) *passkeyUser {
+	mac := hmac.New(sha256.New, s.decoyKey)
+	mac.Write([]byte(normalizeEmail(email)))
+	id := mac.Sum(nil)
+
+	return &passkeyUser{
+		user:        &models.User{ID: hex.EncodeToString(id[:16]), Email: email},
+		credentials: []webauthn.Credential{{ID: id}},
+	}
+}
+
+// FinishLogin verifies the assertion, checks the sign count and issues a JWT
+func (s *PasskeyService) FinishLogin(sessionID string, body io.Reader) (*models.AuthResponse, error) {
+	session, err := s.takeSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.decoy {
+		return nil, ErrPasskeyVerification
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, ErrPasskeyVerification
+	}
+
+	var (
+		user       *models.User
+		credential *webauthn.Credential
+	)
+
+	if session.userID != "" {
+		var exists bool
+		user, exists = s.authService.GetUserByID(session.userID)
+		if !exists {
+			return nil, ErrUserNotFound
+		}
+		credential, err = s.webAuthn.ValidateLogin(s.webAuthnUser(user), *session.data, parsed)
+	} else {
+		credential, err = s.webAuthn.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+			found, exists := s.authService.GetUserByID(string(userHandle))
+			if !exists {
+				return nil, ErrUserNotFound
+			}
+			user = found
+			return s.webAuthnUser(found), nil
+		}, *session.data, parsed)
+	}
+	if err != nil {
+		return nil, ErrPasskeyVerification
+	}
+
+	// A sign count that didn't increase means the private key may exist on more than
+	// one authenticator, so the assertion is rejected rather than trusted.
+	if credential.Authenticator.CloneWarning {
+		return nil, ErrPasskeyCloned
+	}
+
+	s.mu.Lock()
+	credID := base64.RawURLEncoding.EncodeToString(credential.ID)
+	for _, stored := range s.credentials[user.ID] {
+		if stored.ID == credID {
+			stored.SignCount = credential.Authenticator.SignCount
+			stored.BackupState = credential.Flags.BackupState
+			stored.LastUsedAt = time.Now()
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	return s.authService.startSession(user.ID, LoginMethodPasskey)
+}
+
+// GetCredentials returns copies of the passkeys registered by a user
+func (s *PasskeyService) GetCredentials(userID string) []*models.PasskeyCredential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*models.PasskeyCredential, len(s.credentials[userID]))
+	for i, stored := range s.credentials[userID] {
+		result[i] = copyPasskeyCredential(stored)
+	}
+	return result
+}
+
+// copyPasskeyCredential returns a snapshot of a credential that is safe to use after
+// the lock is released, since logins update the stored sign count
+func copyPasskeyCredential(stored *models.PasskeyCredential) *models.PasskeyCredential {
+	c := *stored
+	c.PublicKey = slices.Clone(stored.PublicKey)
+	c.AAGUID = slices.Clone(stored.AAGUID)
+	c.Transports = slices.Clone(stored.Transports)
+	return &c
+}
+
+// DeleteCredentials removes all passkeys registered by a user
+func (s *PasskeyService) DeleteCredentials(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.credentials, userID)
+	for id, session := range s.sessions {
+		if session.userID == userID {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// storeSession saves ceremony state under a new random session ID
+func (s *PasskeyService) storeSession(session *passkeySession) (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	sessionID := hex.EncodeToString(idBytes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop abandoned ceremonies while we hold the lock
+	now := time.Now()
+	for id, session := range s.sessions {
+		if now.After(session.data.Expires) {
+			delete(s.sessions, id)
+		}
+	}
+
+	s.sessions[sessionID] = session
+	return sessionID, nil
+}
+
+// takeSession removes and returns ceremony state, so each session can be finished once
+func (s *PasskeyService) takeSession(sessionID string) (*passkeySession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[sessionID]
+	if !exists {
+		return nil, ErrPasskeySessionNotFound
+	}
+	delete(s.sessions, sessionID)
+
+	if time.Now().After(session.data.Expires) {
+		return nil, ErrPasskeySessionNotFound
+	}
+	return session, nil
+}
+
+// webAuthnUser adapts a user and their stored passkeys to the webauthn.User interface
+func (s *PasskeyService) webAuthnUser(user *models.User) *passkeyUser {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wu := &passkeyUser{user: user}
+	for _, stored := range s.credentials[user.ID] {
+		id, err := base64.RawURLEncoding.DecodeString(stored.ID)
+		if err != nil {
+			continue
+		}
+		transports := make([]protocol.AuthenticatorTransport, 0, len(stored.Transports))
+		for _, t := range stored.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+		wu.credentials = append(wu.credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       stored.PublicKey,
+			AttestationType: stored.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				UserPresent:    true,
+				BackupEligible: stored.BackupEligible,
+				BackupState:    stored.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    stored.AAGUID,
+				SignCount: stored.SignCount,
+			},
+		})
+	}
+	return wu
+}
+
+// passkeyUser implements webauthn.User
+type passkeyUser struct {
+	user        *models.User
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte                         { return []byte(u.user.ID) }
+func (u *passkeyUser) WebAuthnName() string                       { return u.user.Email }
+func (u *passkeyUser) WebAuthnDisplayName() string                { return u.user.Email }
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+func newTestPasskeyService(t *testing.T) (*PasskeyService, *UserService) {
+	t.Helper()
+	logger := newTestLogger()
+	emailService, userService := newTestUsers(logger, config.RolesConfig{})
+	authService := NewAuthService(emailService, userService, config.AuthConfig{JWTSecret: "test-secret"}, testBaseURL, logger)
+
+	s, err := NewPasskeyService(authService, config.PasskeyConfig{
+		RPID:      "localhost",
+		RPName:    "Test",
+		RPOrigins: []string{testBaseURL},
+	})
+	if err != nil {
+		t.Fatalf("NewPasskeyService: %v", err)
+	}
+	return s, userService
+}
+
+func TestGetCredentialsReturnsCopies(t *testing.T) {
+	s, _ := newTestPasskeyService(t)
+	s.credentials["user-1"] = []*models.PasskeyCredential{{
+		ID:         base64.RawURLEncoding.EncodeToString([]byte("cred-1")),
+		UserID:     "user-1",
+		PublicKey:  []byte{1, 2, 3},
+		AAGUID:     []byte{4, 5, 6},
+		Transports: []string{"usb"},
+		SignCount:  7,
+	}}
+
+	got := s.GetCredentials("user-1")
+	if len(got) != 1 {
+		t.Fatalf("got %d credentials, want 1", len(got))
+	}
+	got[0].SignCount = 100
+	got[0].PublicKey[0] = 9
+	got[0].AAGUID[0] = 9
+	got[0].Transports[0] = "nfc"
+
+	stored := s.credentials["user-1"][0]
+	if stored.SignCount != 7 {
+		t.Errorf("stored sign count changed to %d", stored.SignCount)
+	}
+	if !bytes.Equal(stored.PublicKey, []byte{1, 2, 3}) || !bytes.Equal(stored.AAGUID, []byte{4, 5, 6}) {
+		t.Errorf("stored key material changed: %v %v", stored.PublicKey, stored.AAGUID)
+	}
+	if stored.Transports[0] != "usb" {
+		t.Errorf("stored transports changed to %v", stored.Transports)
+	}
+}
+
+func TestBeginLoginWithoutPasskeysReturnsDecoyChallenge(t *testing.T) {
+	s, userService := newTestPasskeyService(t)
+	userService.GetOrCreateUser("nopasskey@example.com")
+
+	for _, email := range []string{"unknown@example.com", "nopasskey@example.com"} {
+		sessionID, assertion, err := s.BeginLogin(email)
+		if err != nil {
+			t.Fatalf("BeginLogin(%q): %v", email, err)
+		}
+		allowed := assertion.Response.AllowedCredentials
+		if len(allowed) != 1 {
+			t.Fatalf("BeginLogin(%q) allowed %d credentials, want 1", email, len(allowed))
+		}
+
+		_, again, err := s.BeginLogin(strings.ToUpper(email))
+		if err != nil {
+			t.Fatalf("BeginLogin(%q) again: %v", email, err)
+		}
+		if !bytes.Equal(again.Response.AllowedCredentials[0].CredentialID, allowed[0].CredentialID) {
+			t.Errorf("decoy credential for %q changed between requests", email)
+		}
+
+		if _, err := s.FinishLogin(sessionID, strings.NewReader("{}")); err != ErrPasskeyVerification {
+			t.Errorf("FinishLogin for %q = %v, want ErrPasskeyVerification", email, err)
+		}
+	}
+}
+
+func TestBeginLoginWithPasskeysAllowsRegisteredCredentials(t *testing.T) {
+	s, userService := newTestPasskeyService(t)
+	user := userService.GetOrCreateUser("user@example.com")
+	credentialID := []byte("registered-credential")
+	s.credentials[user.ID] = []*models.PasskeyCredential{{
+		ID:     base64.RawURLEncoding.EncodeToString(credentialID),
+		UserID: user.ID,
+	}}
+
+	sessionID, assertion, err := s.BeginLogin("user@example.com")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	allowed := assertion.Response.AllowedCredentials
+	if len(allowed) != 1 || !bytes.Equal(allowed[0].CredentialID, credentialID) {
+		t.Fatalf("allowed credentials = %v, want the registered one", allowed)
+	}
+	if session := s.sessions[sessionID]; session.decoy || session.userID != user.ID {
+		t.Errorf("session = %+v, want a real session for %s", session, user.ID)
+	}
+}
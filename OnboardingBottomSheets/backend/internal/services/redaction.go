@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"onboarding-backend/internal/models"
+)
+
+// Kinds of personal data the redactor can remove
+const (
+	RedactEmails = "email"
+	RedactPhones = "phone"
+	RedactCards  = "card"
+	RedactTokens = "token"
+)
+
+// redactionRule replaces matches of pattern that pass the optional validate func
+type redactionRule struct {
+	kind        string
+	pattern     *regexp.Regexp
+	validate    func(match string) bool
+	replacement string
+}
+
+// redactionRules are applied in order, so card numbers are replaced before they
+// can be mistaken for phone numbers
+var redactionRules = []redactionRule{
+	{
+		kind:        RedactEmails,
+		pattern:     regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`),
+		replacement: "[email]",
+	},
+	{
+		kind:        RedactTokens,
+		pattern:     regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/=\-]+|\beyJ[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]*|\b[A-Za-z0-9_]{32,}\b`),
+		replacement: "[token]",
+	},
+	{
+		kind:        RedactCards,
+		pattern:     regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		validate:    luhnValid,
+		replacement: "[card]",
+	},
+	{
+		kind:        RedactPhones,
+		pattern:     regexp.MustCompile(`(?:\+|\(|\b)\d[\d\s().\-]{5,}\d\b`),
+		validate:    looksLikePhone,
+		replacement: "[phone]",
+	},
+}
+
+var (
+	datePattern = regexp.MustCompile(`^\d{4}[-/.]\d{1,2}[-/.]\d{1,2}$`)
+	ipPattern   = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}$`)
+)
+
+// digitsOf returns only the digits in s
+func digitsOf(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by card numbers
+func luhnValid(s string) bool {
+	digits := digitsOf(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// looksLikePhone filters phone-number candidates, skipping dates and IP addresses
+func looksLikePhone(s string) bool {
+	s = strings.TrimSpace(s)
+	if n := len(digitsOf(s)); n < 7 || n > 15 {
+		return false
+	}
+	return !datePattern.MatchString(s) && !ipPattern.MatchString(s)
+}
+
+// Redactor removes personal data from text that leaves the system through
+// integrations, exports and logs. Stored data is never modified.
+type Redactor struct {
+	rules []redactionRule
+}
+
+// NewRedactor creates a redactor for the given kinds (email, phone, card, token)
+func NewRedactor(kinds ...string) (*Redactor, error) {
+	enabled := make(map[string]bool)
+	for _, kind := range kinds {
+		if kind = strings.ToLower(strings.TrimSpace(kind)); kind != "" {
+			enabled[kind] = true
+		}
+	}
+
+	// Rules keep their built-in order regardless of how kinds were listed
+	r := &Redactor{}
+	for _, rule := range redactionRules {
+		if enabled[rule.kind] {
+			r.rules = append(r.rules, rule)
+			delete(enabled, rule.kind)
+		}
+	}
+	for kind := range enabled {
+		return nil, fmt.Errorf("unknown redaction kind %q", kind)
+	}
+	return r, nil
+}
+
+// Redact replaces personal data in text with placeholders such as [email]
+func (r *Redactor) Redact(text string) string {
+	for _, rule := range r.rules {
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.validate != nil && !rule.validate(match) {
+				return match
+			}
+			return rule.replacement
+		})
+	}
+	return text
+}
+
+// RedactFeedback returns a copy of the feedback with personal data removed from
+// its email, free-text fields and replies
+func (r *Redactor) RedactFeedback(fb *models.Feedback) *models.Feedback {
+	c := copyFeedback(fb)
+	c.Email = r.Redact(c.Email)
+	c.Content = r.Redact(c.Content)
+	c.Screen = r.Redact(c.Screen)
+	for i, tag := range c.Tags {
+		c.Tags[i] = r.Redact(tag)
+	}
+	for i, reply := range c.Replies {
+		redacted := *reply
+		redacted.Content = r.Redact(reply.Content)
+		c.Replies[i] = &redacted
+	}
+	return c
+}
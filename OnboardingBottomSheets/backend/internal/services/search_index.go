@@ -0,0 +1,328 @@
+package services
+
+import (
+	"errors"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"onboarding-backend/internal/models"
+)
+
+var (
+	ErrInvalidSearchQuery = errors.New("search query has no searchable terms")
+)
+
+const (
+	// snippetTokensBefore and snippetTokensAfter size the context around the first match
+	snippetTokensBefore = 8
+	snippetTokensAfter  = 20
+)
+
+// stopWords are skipped in plain query terms and keyword counts, but still indexed so phrases match
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "if": true, "in": true, "into": true, "is": true,
+	"it": true, "no": true, "not": true, "of": true, "on": true, "or": true, "so": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true, "with": true,
+	"all": true, "also": true, "been": true, "can": true, "from": true, "had": true,
+	"has": true, "have": true, "just": true, "than": true, "then": true, "there": true,
+	"they": true, "very": true, "were": true, "what": true, "when": true, "will": true,
+	"would": true, "you": true, "your": true,
+}
+
+// searchToken is a stemmed term and where the original word sits in the text
+type searchToken struct {
+	term       string
+	start, end int // byte offsets
+}
+
+// tokenize splits text into lowercase stemmed terms. Apostrophes inside words are
+// dropped so "don't" matches "dont".
+func tokenize(text string) []searchToken {
+	var tokens []searchToken
+	var word strings.Builder
+	start := -1
+
+	flush := func(end int) {
+		if start >= 0 && word.Len() > 0 {
+			tokens = append(tokens, searchToken{term: stem(word.String()), start: start, end: end})
+		}
+		word.Reset()
+		start = -1
+	}
+
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+			word.WriteRune(unicode.ToLower(r))
+		case (r == '\'' || r == '’') && start >= 0:
+			// part of the word, but not of the term
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return tokens
+}
+
+// indexedFeedback is the indexed form of one feedback entry
+type indexedFeedback struct {
+	content   string
+	tokens    []searchToken
+	createdAt time.Time
+}
+
+// SearchHit is one matching feedback entry
+type SearchHit struct {
+	FeedbackID string
+	Score      float64
+	Snippet    string // HTML-escaped, with matches wrapped in <mark>
+}
+
+// SearchIndex is an in-memory inverted index over feedback content
+type SearchIndex struct {
+	postings map[string]map[string][]int // term -> feedbackID -> token positions
+	docs     map[string]*indexedFeedback // feedbackID -> indexed entry
+	mu       sync.RWMutex
+}
+
+// NewSearchIndex creates an empty search index
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		postings: make(map[string]map[string][]int),
+		docs:     make(map[string]*indexedFeedback),
+	}
+}
+
+// Add indexes feedback, replacing any earlier version of it
+func (ix *SearchIndex) Add(fb *models.Feedback) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeLocked(fb.ID)
+
+	doc := &indexedFeedback{
+		content:   fb.Content,
+		tokens:    tokenize(fb.Content),
+		createdAt: fb.CreatedAt,
+	}
+	ix.docs[fb.ID] = doc
+	for pos, token := range doc.tokens {
+		if ix.postings[token.term] == nil {
+			ix.postings[token.term] = make(map[string][]int)
+		}
+		ix.postings[token.term][fb.ID] = append(ix.postings[token.term][fb.ID], pos)
+	}
+}
+
+// Remove drops feedback from the index
+func (ix *SearchIndex) Remove(feedbackID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeLocked(feedbackID)
+}
+
+// removeLocked drops feedback from the index. Must be called with the lock held.
+func (ix *SearchIndex) removeLocked(feedbackID string) {
+	doc, exists := ix.docs[feedbackID]
+	if !exists {
+		return
+	}
+	for _, token := range doc.tokens {
+		delete(ix.postings[token.term], feedbackID)
+		if len(ix.postings[token.term]) == 0 {
+			delete(ix.postings, token.term)
+		}
+	}
+	delete(ix.docs, feedbackID)
+}
+
+// Rebuild replaces the index contents with the given feedback. The new index is built
+// on the side and swapped in, so searches meanwhile see the old contents.
+func (ix *SearchIndex) Rebuild(feedback []*models.Feedback) {
+	fresh := NewSearchIndex()
+	for _, fb := range feedback {
+		fresh.Add(fb)
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.postings, ix.docs = fresh.postings, fresh.docs
+}
+
+// searchClause is a single term or a quoted phrase; every clause must match
+type searchClause []string
+
+// parseSearchQuery splits a query into terms and "quoted phrases". Stop words are
+// dropped from plain terms but kept inside phrases.
+func parseSearchQuery(query string) []searchClause {
+	var clauses []searchClause
+	for i, part := range strings.Split(query, `"`) {
+		inPhrase := i%2 == 1
+		tokens := tokenize(part)
+		if inPhrase && len(tokens) > 0 {
+			phrase := make(searchClause, len(tokens))
+			for j, token := range tokens {
+				phrase[j] = token.term
+			}
+			clauses = append(clauses, phrase)
+			continue
+		}
+		for _, token := range tokens {
+			if !stopWords[strings.ToLower(part[token.start:token.end])] {
+				clauses = append(clauses, searchClause{token.term})
+			}
+		}
+	}
+	return clauses
+}
+
+// Search returns feedback matching every term and phrase in the query, best matches first
+func (ix *SearchIndex) Search(query string, limit int) ([]SearchHit, error) {
+	clauses := parseSearchQuery(query)
+	if len(clauses) == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// Match each clause, keeping the token positions that matched per entry
+	scores := make(map[string]float64)
+	highlights := make(map[string]map[int]bool)
+	for i, clause := range clauses {
+		matches := ix.matchClause(clause)
+		idf := math.Log(1 + float64(len(ix.docs))/float64(len(matches)+1))
+
+		for id, starts := range matches {
+			if i > 0 {
+				if _, stillMatching := scores[id]; !stillMatching {
+					continue
+				}
+			}
+			scores[id] += (1 + math.Log(float64(len(starts)))) * idf
+			if highlights[id] == nil {
+				highlights[id] = make(map[int]bool)
+			}
+			for _, start := range starts {
+				for j := range clause {
+					highlights[id][start+j] = true
+				}
+			}
+		}
+		if i > 0 {
+			for id := range scores {
+				if _, matched := matches[id]; !matched {
+					delete(scores, id)
+				}
+			}
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, SearchHit{FeedbackID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return ix.docs[hits[i].FeedbackID].createdAt.After(ix.docs[hits[j].FeedbackID].createdAt)
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for i := range hits {
+		hits[i].Snippet = ix.docs[hits[i].FeedbackID].snippet(highlights[hits[i].FeedbackID])
+	}
+	return hits, nil
+}
+
+// matchClause returns the entries containing the clause and the token positions where
+// each match starts. Must be called with the read lock held.
+func (ix *SearchIndex) matchClause(clause searchClause) map[string][]int {
+	result := make(map[string][]int)
+	for id, positions := range ix.postings[clause[0]] {
+		if len(clause) == 1 {
+			result[id] = positions
+			continue
+		}
+
+		tokens := ix.docs[id].tokens
+		for _, start := range positions {
+			if start+len(clause) > len(tokens) {
+				break
+			}
+			matched := true
+			for j := 1; j < len(clause); j++ {
+				if tokens[start+j].term != clause[j] {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				result[id] = append(result[id], start)
+			}
+		}
+	}
+	return result
+}
+
+// snippet returns an excerpt around the first highlighted token with every
+// highlighted token wrapped in <mark>
+func (doc *indexedFeedback) snippet(highlighted map[int]bool) string {
+	if len(doc.tokens) == 0 {
+		return html.EscapeString(doc.content)
+	}
+
+	first := len(doc.tokens)
+	for pos := range highlighted {
+		first = min(first, pos)
+	}
+	if first == len(doc.tokens) {
+		first = 0
+	}
+
+	from := max(first-snippetTokensBefore, 0)
+	to := min(first+snippetTokensAfter, len(doc.tokens)-1)
+	start, end := doc.tokens[from].start, doc.tokens[to].end
+	if from == 0 {
+		start = 0
+	}
+	if to == len(doc.tokens)-1 {
+		end = len(doc.content)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	offset := start
+	for pos := from; pos <= to; pos++ {
+		if !highlighted[pos] {
+			continue
+		}
+		token := doc.tokens[pos]
+		b.WriteString(html.EscapeString(doc.content[offset:token.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(doc.content[token.start:token.end]))
+		b.WriteString("</mark>")
+		offset = token.end
+	}
+	b.WriteString(html.EscapeString(strings.TrimRightFunc(doc.content[offset:end], unicode.IsSpace)))
+	if end < len(doc.content) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/models"
+)
+
+// newTestIndex indexes content under IDs doc-0, doc-1, ..., oldest first
+func newTestIndex(content ...string) *SearchIndex {
+	ix := NewSearchIndex()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, text := range content {
+		ix.Add(&models.Feedback{
+			ID:        fmt.Sprintf("doc-%d", i),
+			Content:   text,
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	return ix
+}
+
+// hitIDs returns the matching IDs sorted, for comparisons that ignore ranking
+func hitIDs(hits []SearchHit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.FeedbackID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSearchMatchesEveryTermAndPhrase(t *testing.T) {
+	ix := newTestIndex(
+		"The app crashes when I open settings",       // doc-0
+		"Dark mode is broken on the settings screen", // doc-1
+		"Please add a mode that is dark",             // doc-2
+		"Crashing constantly since the update",       // doc-3
+		"Don't like the new icon",                    // doc-4
+	)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"crash", []string{"doc-0", "doc-3"}},
+		{"CRASHED", []string{"doc-0", "doc-3"}},
+		{"crash settings", []string{"doc-0"}},
+		{"dark mode", []string{"doc-1", "doc-2"}},
+		{`"dark mode"`, []string{"doc-1"}},
+		{`"mode is broken"`, []string{"doc-1"}},
+		{`settings "dark mode"`, []string{"doc-1"}},
+		{`"mode dark"`, []string{}},
+		{"dont", []string{"doc-4"}},
+		{"the crash", []string{"doc-0", "doc-3"}},
+		{"missing", []string{}},
+	}
+	for _, tt := range tests {
+		hits, err := ix.Search(tt.query, 0)
+		if err != nil {
+			t.Errorf("Search(%q): %v", tt.query, err)
+			continue
+		}
+		if got := hitIDs(hits); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchRejectsQueriesWithoutTerms(t *testing.T) {
+	ix := newTestIndex("The app is great")
+
+	for _, query := range []string{"", "   ", "the", "is it", `""`, "?!"} {
+		if _, err := ix.Search(query, 0); err != ErrInvalidSearchQuery {
+			t.Errorf("Search(%q) error = %v, want %v", query, err, ErrInvalidSearchQuery)
+		}
+	}
+}
+
+func TestSearchRanksMoreMatchesFirstThenNewest(t *testing.T) {
+	ix := newTestIndex(
+		"Crash on launch",                // doc-0
+		"Crash, crash and another crash", // doc-1
+		"Crash on resume",                // doc-2
+	)
+
+	hits, err := ix.Search("crash", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, hit := range hits {
+		got = append(got, hit.FeedbackID)
+	}
+	if want := []string{"doc-1", "doc-2"}; !slices.Equal(got, want) {
+		t.Errorf("ranked %v, want %v", got, want)
+	}
+}
+
+func TestSearchSnippetsHighlightAndEscape(t *testing.T) {
+	var words []string
+	for i := 0; i < 60; i++ {
+		words = append(words, fmt.Sprintf("w%d", i))
+	}
+	words[20] = "crashing"
+
+	ix := newTestIndex(
+		`Tap <Settings> & then "dark mode"`,
+		strings.Join(words, " "),
+	)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"settings", "Tap &lt;<mark>Settings</mark>&gt; &amp; then &#34;dark mode&#34;"},
+		{`"dark mode"`, "Tap &lt;Settings&gt; &amp; then &#34;<mark>dark</mark> <mark>mode</mark>&#34;"},
+		{"crash", "… " + strings.Join(words[12:20], " ") + " <mark>crashing</mark> " + strings.Join(words[21:41], " ") + " …"},
+	}
+	for _, tt := range tests {
+		hits, err := ix.Search(tt.query, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != 1 {
+			t.Fatalf("Search(%q) returned %d hits, want 1", tt.query, len(hits))
+		}
+		if hits[0].Snippet != tt.want {
+			t.Errorf("Search(%q) snippet\n got %q\nwant %q", tt.query, hits[0].Snippet, tt.want)
+		}
+	}
+}
+
+func TestSearchIndexAddReplacesAndRemoveDrops(t *testing.T) {
+	ix := newTestIndex("Crash on launch")
+
+	ix.Add(&models.Feedback{ID: "doc-0", Content: "Slow on launch"})
+	if hits, _ := ix.Search("crash", 0); len(hits) != 0 {
+		t.Errorf("old content still matches after an edit: %v", hitIDs(hits))
+	}
+	if hits, _ := ix.Search("slow", 0); len(hits) != 1 {
+		t.Errorf("new content matched %d entries, want 1", len(hits))
+	}
+
+	ix.Remove("doc-0")
+	if hits, _ := ix.Search("launch", 0); len(hits) != 0 {
+		t.Errorf("removed entry still matches: %v", hitIDs(hits))
+	}
+}
+
+func TestSearchIndexRebuildSwapsContents(t *testing.T) {
+	ix := newTestIndex("Crash on launch", "Crash on resume")
+	replacement := []*models.Feedback{
+		{ID: "doc-0", Content: "Crash on launch"},
+		{ID: "doc-2", Content: "Crash after the update"},
+	}
+	for i := 0; i < 200; i++ {
+		replacement = append(replacement, &models.Feedback{ID: fmt.Sprintf("filler-%d", i), Content: "Nice app"})
+	}
+
+	// Searches during a rebuild keep finding the entry present before and after it
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			hits, err := ix.Search("launch", 0)
+			if err != nil || len(hits) != 1 {
+				t.Errorf("search during rebuild returned %v, %v", hitIDs(hits), err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		ix.Rebuild(replacement)
+	}
+	close(stop)
+	wg.Wait()
+
+	hits, err := ix.Search("crash", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := hitIDs(hits), []string{"doc-0", "doc-2"}; !slices.Equal(got, want) {
+		t.Errorf("after rebuild matched %v, want %v", got, want)
+	}
+}
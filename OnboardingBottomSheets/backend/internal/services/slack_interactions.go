@@ -0,0 +1,250 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"onboarding-backend/internal/models"
+)
+
+var (
+	ErrSlackNotConfigured    = errors.New("slack signing secret is not configured")
+	ErrSlackSignatureInvalid = errors.New("invalid slack signature")
+	ErrSlackRequestExpired   = errors.New("slack request timestamp is outside the replay window")
+	ErrSlackRequestReplayed  = errors.New("slack request was already handled")
+	ErrUnknownSlackAction    = errors.New("unknown slack action")
+	ErrSlackResponseURL      = errors.New("slack response_url is not a Slack URL")
+)
+
+// Action IDs of the buttons and inputs on feedback messages
+const (
+	SlackActionResolve      = "feedback_resolve"
+	SlackActionReply        = "feedback_reply"
+	SlackActionTicket       = "feedback_ticket"
+	SlackReplyBlockID       = "feedback_reply"
+	SlackReplyInputActionID = "feedback_reply_text"
+)
+
+// SlackReplayWindow is how far a request timestamp may be from now, as Slack recommends
+const SlackReplayWindow = 5 * time.Minute
+
+// slackResponseTimeout bounds posting a deferred result to a response_url
+const slackResponseTimeout = 10 * time.Second
+
+// SlackInteraction is the part of a Slack interaction payload the backend uses
+type SlackInteraction struct {
+	Type string `json:"type"` // block_actions for button clicks
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	State struct {
+		Values map[string]map[string]struct {
+			Value string `json:"value"`
+		} `json:"values"`
+	} `json:"state"`
+	ResponseURL string `json:"response_url"`
+}
+
+// Deferred reports whether the interaction's action can take longer than the 3 seconds
+// Slack waits for an acknowledgement. Creating a ticket calls the tracker and replying
+// emails the user, so their results are posted to ResponseURL instead.
+func (i *SlackInteraction) Deferred() bool {
+	if len(i.Actions) == 0 || i.ResponseURL == "" {
+		return false
+	}
+	switch i.Actions[0].ActionID {
+	case SlackActionTicket, SlackActionReply:
+		return true
+	}
+	return false
+}
+
+// replyText returns what was typed in the message's reply box
+func (i *SlackInteraction) replyText() string {
+	return strings.TrimSpace(i.State.Values[SlackReplyBlockID][SlackReplyInputActionID].Value)
+}
+
+// SlackInteractionService verifies Slack interaction requests and applies the
+// actions taken on feedback messages
+type SlackInteractionService struct {
+	signingSecret   []byte
+	feedbackService *FeedbackService
+	ticketService   *TicketService
+	seen            map[string]time.Time // signature -> when it stops being accepted
+	client          *http.Client
+	responseOrigin  *url.URL // scheme and host a response_url must have
+	mu              sync.Mutex
+}
+
+// NewSlackInteractionService creates a service that verifies requests with the app's
+// signing secret. An empty secret disables interactions.
+func NewSlackInteractionService(signingSecret string, feedbackService *FeedbackService, ticketService *TicketService) *SlackInteractionService {
+	return &SlackInteractionService{
+		signingSecret:   []byte(signingSecret),
+		feedbackService: feedbackService,
+		ticketService:   ticketService,
+		seen:            make(map[string]time.Time),
+		client:          &http.Client{Timeout: slackResponseTimeout},
+		responseOrigin:  &url.URL{Scheme: "https", Host: "hooks.slack.com"},
+	}
+}
+
+// SetResponseOrigin replaces https://hooks.slack.com as the only origin Respond posts
+// to, for pointing it at a local server
+func (s *SlackInteractionService) SetResponseOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responseOrigin = &url.URL{Scheme: u.Scheme, Host: u.Host}
+	return nil
+}
+
+// Enabled reports whether a signing secret is configured
+func (s *SlackInteractionService) Enabled() bool {
+	return len(s.signingSecret) > 0
+}
+
+// Verify checks Slack's X-Slack-Signature for a raw request body. Requests with a
+// timestamp outside the replay window are rejected, and so is a second request with a
+// signature already seen inside it.
+func (s *SlackInteractionService) Verify(timestamp, signature string, body []byte) error {
+	if !s.Enabled() {
+		return ErrSlackNotConfigured
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSlackSignatureInvalid
+	}
+	now := time.Now()
+	sentAt := time.Unix(seconds, 0)
+	if now.Sub(sentAt) > SlackReplayWindow || sentAt.Sub(now) > SlackReplayWindow {
+		return ErrSlackRequestExpired
+	}
+
+	mac := hmac.New(sha256.New, s.signingSecret)
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrSlackSignatureInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sig, expiresAt := range s.seen {
+		if now.After(expiresAt) {
+			delete(s.seen, sig)
+		}
+	}
+	if _, replayed := s.seen[signature]; replayed {
+		return ErrSlackRequestReplayed
+	}
+	s.seen[signature] = sentAt.Add(SlackReplayWindow)
+	return nil
+}
+
+// Respond posts a message to an interaction's response_url, shown only to the Slack
+// user who took the action. URLs outside https://hooks.slack.com are refused, so a
+// payload can't make the server post to an arbitrary host.
+func (s *SlackInteractionService) Respond(ctx context.Context, responseURL, text string) error {
+	target, err := url.Parse(responseURL)
+	if err != nil {
+		return ErrSlackResponseURL
+	}
+	s.mu.Lock()
+	origin := s.responseOrigin
+	s.mu.Unlock()
+	if target.Scheme != origin.Scheme || target.Host != origin.Host || target.User != nil {
+		return ErrSlackResponseURL
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"response_type":    "ephemeral",
+		"replace_original": false,
+		"text":             text,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack response_url returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// HandleInteraction applies the first action in a block_actions payload to its
+// feedback and returns a message for the Slack user
+func (s *SlackInteractionService) HandleInteraction(ctx context.Context, interaction *SlackInteraction) (string, error) {
+	if interaction.Type != "block_actions" || len(interaction.Actions) == 0 {
+		return "", ErrUnknownSlackAction
+	}
+	action := interaction.Actions[0]
+	feedbackID := action.Value
+	// Slack users aren't app users, so changes are attributed to their Slack ID
+	actor := "slack:" + interaction.User.ID
+
+	switch action.ActionID {
+	case SlackActionResolve:
+		if _, err := s.feedbackService.UpdateStatus(feedbackID, actor, models.FeedbackStatusResolved, "Resolved from Slack by @"+interaction.User.Username); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ Feedback %s marked resolved", feedbackID), nil
+
+	case SlackActionReply:
+		text := interaction.replyText()
+		if text == "" {
+			return "✏️ Type a reply in the box above first", nil
+		}
+		if _, err := s.feedbackService.AddReply(ctx, feedbackID, actor, models.RoleSupport, models.FeedbackReplyRequest{Content: text}); err != nil {
+			return "", err
+		}
+		return "💬 Reply sent to the user", nil
+
+	case SlackActionTicket:
+		feedback, err := s.ticketService.CreateTicket(feedbackID)
+		if err != nil {
+			return "", err
+		}
+		return "🎫 Ticket created: " + feedback.TicketURL, nil
+	}
+	return "", ErrUnknownSlackAction
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// signSlackRequest returns the timestamp and X-Slack-Signature Slack would send
+func signSlackRequest(secret string, sentAt time.Time, body []byte) (string, string) {
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return timestamp, "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSlackVerify(t *testing.T) {
+	body := []byte("payload=%7B%7D")
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		secret string // used to sign
+		sentAt time.Time
+		want   error
+	}{
+		{"valid", "signing-secret", now, nil},
+		{"wrong secret", "other-secret", now, ErrSlackSignatureInvalid},
+		{"too old", "signing-secret", now.Add(-SlackReplayWindow - time.Minute), ErrSlackRequestExpired},
+		{"in the future", "signing-secret", now.Add(SlackReplayWindow + time.Minute), ErrSlackRequestExpired},
+	}
+	for _, tt := range tests {
+		s := NewSlackInteractionService("signing-secret", nil, nil)
+		timestamp, signature := signSlackRequest(tt.secret, tt.sentAt, body)
+		if err := s.Verify(timestamp, signature, body); err != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSlackVerifyRejectsTamperingAndBadInput(t *testing.T) {
+	s := NewSlackInteractionService("signing-secret", nil, nil)
+	body := []byte("payload=%7B%7D")
+	timestamp, signature := signSlackRequest("signing-secret", time.Now(), body)
+
+	if err := s.Verify(timestamp, signature, []byte("payload=%7B%22x%22%7D")); err != ErrSlackSignatureInvalid {
+		t.Errorf("changed body: Verify = %v, want ErrSlackSignatureInvalid", err)
+	}
+	if err := s.Verify("not-a-number", signature, body); err != ErrSlackSignatureInvalid {
+		t.Errorf("bad timestamp: Verify = %v, want ErrSlackSignatureInvalid", err)
+	}
+	if err := s.Verify(timestamp, "", body); err != ErrSlackSignatureInvalid {
+		t.Errorf("missing signature: Verify = %v, want ErrSlackSignatureInvalid", err)
+	}
+	if err := NewSlackInteractionService("", nil, nil).Verify(timestamp, signature, body); err != ErrSlackNotConfigured {
+		t.Errorf("no secret: Verify = %v, want ErrSlackNotConfigured", err)
+	}
+}
+
+func TestSlackVerifyRejectsReplay(t *testing.T) {
+	s := NewSlackInteractionService("signing-secret", nil, nil)
+	body := []byte("payload=%7B%7D")
+	timestamp, signature := signSlackRequest("signing-secret", time.Now(), body)
+
+	if err := s.Verify(timestamp, signature, body); err != nil {
+		t.Fatalf("first Verify = %v", err)
+	}
+	if err := s.Verify(timestamp, signature, body); err != ErrSlackRequestReplayed {
+		t.Errorf("replayed Verify = %v, want ErrSlackRequestReplayed", err)
+	}
+}
+
+func TestSlackRespondOnlyPostsToSlack(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer receiver.Close()
+
+	s := NewSlackInteractionService("signing-secret", nil, nil)
+	for _, responseURL := range []string{
+		receiver.URL + "/actions/T1/1/abc",
+		"http://hooks.slack.com/actions/T1/1/abc",
+		"https://hooks.slack.com.example.com/actions/T1/1/abc",
+		"https://user@hooks.slack.com/actions/T1/1/abc",
+		"https://example.com/?r=https://hooks.slack.com/",
+		"not a url\x7f",
+	} {
+		if err := s.Respond(context.Background(), responseURL, "done"); err != ErrSlackResponseURL {
+			t.Errorf("Respond(%q) = %v, want %v", responseURL, err, ErrSlackResponseURL)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Fatalf("%d requests made to a non-Slack response_url", n)
+	}
+
+	if err := s.SetResponseOrigin(receiver.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Respond(context.Background(), receiver.URL+"/actions/T1/1/abc", "done"); err != nil {
+		t.Fatalf("Respond to the configured origin: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests made to the configured origin, want 1", n)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+// SlackDigestDestination is the route destination that batches feedback into the daily digest
+const SlackDigestDestination = "digest"
+
+// SlackRoute sends feedback matching Rule to Channel, or holds it for the digest
+type SlackRoute struct {
+	Rule    FeedbackRule
+	Channel string // "#channel" or an incoming-webhook URL
+	Digest  bool
+}
+
+// SlackRouting decides where feedback is posted in Slack
+type SlackRouting struct {
+	Routes         []SlackRoute // first match wins
+	DefaultChannel string       // used when no route matches
+	DigestChannel  string       // where the daily digest is posted
+	DigestHour     int          // UTC hour the digest is posted
+}
+
+// ParseSlackRoutes parses routes separated by ";", each "<rule> => <destination>", e.g.
+// "max_rating=1 => #feedback-urgent; category=praise => digest". Rules use the
+// ParseFeedbackRule syntax. A destination is a #channel, an https webhook URL or digest.
+func ParseSlackRoutes(spec string) ([]SlackRoute, error) {
+	var routes []SlackRoute
+	for _, routeSpec := range strings.Split(spec, ";") {
+		if strings.TrimSpace(routeSpec) == "" {
+			continue
+		}
+
+		ruleSpec, destination, ok := strings.Cut(routeSpec, "=>")
+		destination = strings.TrimSpace(destination)
+		if !ok || destination == "" {
+			return nil, fmt.Errorf("%w: route %q has no destination", ErrInvalidFeedbackRule, strings.TrimSpace(routeSpec))
+		}
+		rule, err := ParseFeedbackRule(ruleSpec)
+		if err != nil {
+			return nil, err
+		}
+
+		route := SlackRoute{Rule: rule}
+		switch {
+		case destination == SlackDigestDestination:
+			route.Digest = true
+		case strings.HasPrefix(destination, "#"), strings.HasPrefix(destination, "https://"):
+			route.Channel = destination
+		default:
+			return nil, fmt.Errorf("%w: destination %q must be a #channel, an https URL or digest", ErrInvalidFeedbackRule, destination)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// SlackRoutingFromConfig parses the configured routes and channels
+func SlackRoutingFromConfig(cfg config.SlackConfig) (SlackRouting, error) {
+	routes, err := ParseSlackRoutes(cfg.Routes)
+	if err != nil {
+		return SlackRouting{}, err
+	}
+
+	return SlackRouting{
+		Routes:         routes,
+		DefaultChannel: cfg.DefaultChannel,
+		DigestChannel:  cfg.DigestChannel,
+		DigestHour:     cfg.DigestHour,
+	}, nil
+}
+
+// Route returns the first route matching feedback, or one to the default channel
+func (r SlackRouting) Route(feedback *models.Feedback) SlackRoute {
+	for _, route := range r.Routes {
+		if route.Rule.Matches(feedback) {
+			return route
+		}
+	}
+	return SlackRoute{Channel: r.DefaultChannel}
+}
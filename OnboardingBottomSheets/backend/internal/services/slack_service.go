@@ -1,8 +1,13 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"onboarding-backend/internal/models"
@@ -10,61 +15,403 @@ import (
 
 // SlackService is the interface for Slack integration
 type SlackService interface {
-	PublishFeedback(feedback *models.Feedback) error
+	PublishFeedback(ctx context.Context, feedback *models.Feedback) error
+	PublishAttachment(ctx context.Context, feedback *models.Feedback, attachment *models.Attachment, downloadURL string) error
+	// DeleteFromDigest drops feedback waiting for the digest and returns how many entries were dropped
+	DeleteFromDigest(feedbackIDs []string) int
 }
 
-// MockSlackService is a mock implementation of Slack integration
+var (
+	ErrMockSlackFailure = errors.New("mock slack failure")
+	ErrSlackWaitTimeout = errors.New("timed out waiting for slack messages")
+)
+
+// FeedbackLookup returns the current version of stored feedback
+type FeedbackLookup func(feedbackID string) (*models.Feedback, bool)
+
+// MockSlackService is a mock implementation of Slack integration. It records sent
+// messages and doubles as a test double: tests can wait for messages and inject
+// failures and latency. It is safe for concurrent use.
 type MockSlackService struct {
-	messages []SlackMessage
+	messages    []SlackMessage
+	redactor    *Redactor
+	routing     SlackRouting
+	lookup      FeedbackLookup
+	digest      []string      // IDs of feedback waiting for the next digest
+	latency     time.Duration // added before every send
+	failure     error         // returned by every send while set
+	failNext    int           // sends that fail with failNextErr before sending works again
+	failNextErr error
+	changed     chan struct{} // closed and replaced whenever a message is recorded
+	logger      *slog.Logger
+	mu          sync.Mutex
 }
 
 // SlackMessage represents a message sent to Slack
 type SlackMessage struct {
-	Channel   string    `json:"channel"`
-	Text      string    `json:"text"`
-	Timestamp time.Time `json:"timestamp"`
+	Channel   string       `json:"channel"`
+	Text      string       `json:"text"`
+	Blocks    []SlackBlock `json:"blocks,omitempty"` // Block Kit layout; Text is the fallback
+	Timestamp time.Time    `json:"timestamp"`
 }
 
-// NewMockSlackService creates a new mock Slack service
-func NewMockSlackService() *MockSlackService {
+// SlackBlock is a Block Kit block as sent to the Slack API
+type SlackBlock map[string]interface{}
+
+// NewMockSlackService creates a new mock Slack service that picks channels with routing.
+// Personal data is removed from messages with redactor before they are sent. The digest
+// is built from the feedback lookup returns when it is sent.
+func NewMockSlackService(redactor *Redactor, routing SlackRouting, lookup FeedbackLookup, logger *slog.Logger) *MockSlackService {
 	return &MockSlackService{
 		messages: make([]SlackMessage, 0),
+		redactor: redactor,
+		routing:  routing,
+		lookup:   lookup,
+		changed:  make(chan struct{}),
+		logger:   logger,
 	}
 }
 
-// PublishFeedback publishes feedback to Slack (mocked)
-func (s *MockSlackService) PublishFeedback(feedback *models.Feedback) error {
+// PublishFeedback publishes feedback to the channel its route picks, or holds it for
+// the daily digest (mocked)
+func (s *MockSlackService) PublishFeedback(ctx context.Context, feedback *models.Feedback) error {
+	route := s.routing.Route(feedback)
+	if route.Digest {
+		s.mu.Lock()
+		s.digest = append(s.digest, feedback.ID)
+		s.mu.Unlock()
+		return nil
+	}
+
 	// Format the message
+	text := formatFeedbackMessage(s.redactor.RedactFeedback(feedback))
+
+	return s.send(ctx, SlackMessage{
+		Channel:   route.Channel,
+		Text:      text,
+		Blocks:    feedbackMessageBlocks(feedback.ID, text),
+		Timestamp: time.Now(),
+	})
+}
+
+// PublishAttachment posts a link to a file uploaded for already published feedback, in
+// the same channel as the feedback. Nothing is posted for digest feedback; the digest
+// shows how many attachments it has.
+func (s *MockSlackService) PublishAttachment(ctx context.Context, feedback *models.Feedback, attachment *models.Attachment, downloadURL string) error {
+	route := s.routing.Route(feedback)
+	if route.Digest {
+		return nil
+	}
+
 	text := fmt.Sprintf(
-		"📝 *New Feedback Received*\n"+
-			"👤 User: %s\n"+
+		"📎 *Attachment added to feedback* %s\n"+
 			"📧 Email: %s\n"+
-			"📱 Platform: %s\n"+
-			"💬 Feedback: %s\n"+
-			"🕒 Time: %s",
-		feedback.UserID,
-		feedback.Email,
-		feedback.Platform,
-		feedback.Content,
-		feedback.CreatedAt.Format(time.RFC3339),
+			"📄 File: <%s|%s> (%s, %d bytes)",
+		feedback.ID,
+		s.redactor.Redact(feedback.Email),
+		downloadURL,
+		s.redactor.Redact(attachment.Filename),
+		attachment.ContentType,
+		attachment.Size,
 	)
 
-	message := SlackMessage{
-		Channel:   "#feedback",
+	return s.send(ctx, SlackMessage{
+		Channel:   route.Channel,
 		Text:      text,
 		Timestamp: time.Now(),
+	})
+}
+
+// SendDigest posts the feedback held for the digest as one summary message and returns
+// how many entries it contained. Entries show the feedback as it is now, so edits and
+// attachments are included, and feedback since deleted or held by moderation is left
+// out. Nothing is posted when no feedback is waiting. If sending fails the entries are
+// kept for the next digest.
+func (s *MockSlackService) SendDigest(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	pending := s.digest
+	s.digest = nil
+	s.mu.Unlock()
+
+	var entries []*models.Feedback
+	for _, feedbackID := range pending {
+		feedback, exists := s.lookup(feedbackID)
+		if !exists || feedback.ModerationStatus != models.ModerationApproved {
+			continue
+		}
+		entries = append(entries, s.redactor.RedactFeedback(feedback))
+	}
+	if len(entries) == 0 {
+		return 0, nil
 	}
 
-	// Store the message (simulating sending)
-	s.messages = append(s.messages, message)
+	err := s.send(ctx, SlackMessage{
+		Channel:   s.routing.DigestChannel,
+		Text:      formatDigestMessage(entries),
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		s.mu.Lock()
+		s.digest = append(pending, s.digest...)
+		s.mu.Unlock()
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// DeleteFromDigest drops feedback waiting for the next digest, such as the feedback of
+// a deleted account
+func (s *MockSlackService) DeleteFromDigest(feedbackIDs []string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.digest[:0]
+	for _, id := range s.digest {
+		if !slices.Contains(feedbackIDs, id) {
+			kept = append(kept, id)
+		}
+	}
+	removed := len(s.digest) - len(kept)
+	s.digest = kept
+	return removed
+}
+
+// PendingDigest returns how many feedback entries are waiting for the next digest
+func (s *MockSlackService) PendingDigest() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.digest)
+}
+
+// StartDigest posts the digest every day at the routing's digest hour (UTC) until ctx ends
+func (s *MockSlackService) StartDigest(ctx context.Context) {
+	go func() {
+		for {
+			now := time.Now().UTC()
+			next := time.Date(now.Year(), now.Month(), now.Day(), s.routing.DigestHour, 0, 0, 0, time.UTC)
+			if !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if _, err := s.SendDigest(ctx); err != nil {
+				s.logger.ErrorContext(ctx, "failed to send slack digest", "error", err)
+			}
+		}
+	}()
+}
 
-	// Log the message (simulating Slack webhook)
-	log.Printf("📤 [MOCK SLACK] Message sent to %s:\n%s\n", message.Channel, message.Text)
+// formatDigestMessage summarises feedback as one line per entry, oldest first
+func formatDigestMessage(entries []*models.Feedback) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🗞️ *Daily feedback digest* (%d entries)\n", len(entries))
+	for _, feedback := range entries {
+		fmt.Fprintf(&b, "• [%s]", feedback.Platform)
+		if feedback.Rating > 0 {
+			fmt.Fprintf(&b, " %d/5", feedback.Rating)
+		}
+		if feedback.Category != "" {
+			fmt.Fprintf(&b, " %s", feedback.Category)
+		}
+		if n := len(feedback.Attachments); n > 0 {
+			fmt.Fprintf(&b, " 📎%d", n)
+		}
+		fmt.Fprintf(&b, ": %s (%s)\n", excerpt(strings.Join(strings.Fields(feedback.Content), " "), 120), feedback.ID)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatFeedbackMessage renders feedback as a Slack message, including the
+// structured fields that were given
+func formatFeedbackMessage(feedback *models.Feedback) string {
+	var b strings.Builder
+	b.WriteString("📝 *New Feedback Received*\n")
+	fmt.Fprintf(&b, "👤 User: %s\n", feedback.UserID)
+	fmt.Fprintf(&b, "📧 Email: %s\n", feedback.Email)
+	fmt.Fprintf(&b, "📱 Platform: %s\n", feedback.Platform)
+	if feedback.Rating > 0 {
+		fmt.Fprintf(&b, "⭐ Rating: %s (%d/5)\n", strings.Repeat("★", feedback.Rating)+strings.Repeat("☆", 5-feedback.Rating), feedback.Rating)
+	}
+	if feedback.Category != "" {
+		fmt.Fprintf(&b, "🏷️ Category: %s\n", feedback.Category)
+	}
+	if feedback.Screen != "" {
+		fmt.Fprintf(&b, "🧭 Screen: %s\n", feedback.Screen)
+	}
+	if len(feedback.Tags) > 0 {
+		fmt.Fprintf(&b, "🔖 Tags: %s\n", strings.Join(feedback.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "💬 Feedback: %s\n", feedback.Content)
+	fmt.Fprintf(&b, "🕒 Time: %s", feedback.CreatedAt.Format(time.RFC3339))
+	return b.String()
+}
 
+// feedbackMessageBlocks lays out a feedback message with a reply box and buttons that
+// Slack sends back to the interactions endpoint. Every action carries the feedback ID
+// as its value.
+func feedbackMessageBlocks(feedbackID, text string) []SlackBlock {
+	button := func(actionID, label, style string) SlackBlock {
+		b := SlackBlock{
+			"type":      "button",
+			"action_id": actionID,
+			"text":      SlackBlock{"type": "plain_text", "text": label},
+			"value":     feedbackID,
+		}
+		if style != "" {
+			b["style"] = style
+		}
+		return b
+	}
+
+	return []SlackBlock{
+		{
+			"type": "section",
+			"text": SlackBlock{"type": "mrkdwn", "text": text},
+		},
+		{
+			"type":     "input",
+			"block_id": SlackReplyBlockID,
+			"optional": true,
+			"label":    SlackBlock{"type": "plain_text", "text": "Reply to the user"},
+			"element": SlackBlock{
+				"type":      "plain_text_input",
+				"action_id": SlackReplyInputActionID,
+				"multiline": true,
+			},
+		},
+		{
+			"type":     "actions",
+			"block_id": "feedback_actions",
+			"elements": []SlackBlock{
+				button(SlackActionResolve, "Mark resolved", "primary"),
+				button(SlackActionReply, "Reply", ""),
+				button(SlackActionTicket, "Create ticket", ""),
+			},
+		},
+	}
+}
+
+// send records a message as sent (simulating the Slack webhook), applying any injected
+// latency and failures
+func (s *MockSlackService) send(ctx context.Context, message SlackMessage) error {
+	s.mu.Lock()
+	latency := s.latency
+	s.mu.Unlock()
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failure != nil {
+		return s.failure
+	}
+	if s.failNext > 0 {
+		s.failNext--
+		return s.failNextErr
+	}
+
+	s.messages = append(s.messages, message)
+	close(s.changed)
+	s.changed = make(chan struct{})
+
+	s.logger.InfoContext(ctx, "mock slack message sent", "channel", message.Channel, "text", message.Text)
 	return nil
 }
 
-// GetMessages returns all mock messages (for testing/debugging)
+// GetMessages returns a copy of all messages sent so far
 func (s *MockSlackService) GetMessages() []SlackMessage {
-	return s.messages
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.messages)
+}
+
+// MessagesTo returns the messages sent to a channel or webhook URL
+func (s *MockSlackService) MessagesTo(channel string) []SlackMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []SlackMessage
+	for _, message := range s.messages {
+		if message.Channel == channel {
+			result = append(result, message)
+		}
+	}
+	return result
+}
+
+// WaitForMessages blocks until at least n messages have been sent and returns them. On
+// timeout it returns the messages sent so far with ErrSlackWaitTimeout.
+func (s *MockSlackService) WaitForMessages(n int, timeout time.Duration) ([]SlackMessage, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		if len(s.messages) >= n {
+			messages := slices.Clone(s.messages)
+			s.mu.Unlock()
+			return messages, nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return s.GetMessages(), ErrSlackWaitTimeout
+		}
+	}
+}
+
+// SetLatency delays every send by d, simulating a slow Slack API
+func (s *MockSlackService) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// SetFailure makes every send fail with err until it is cleared with nil
+func (s *MockSlackService) SetFailure(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failure = err
+}
+
+// FailNext makes the next n sends fail with err (ErrMockSlackFailure if nil)
+func (s *MockSlackService) FailNext(n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		err = ErrMockSlackFailure
+	}
+	s.failNext, s.failNextErr = n, err
+}
+
+// Reset clears sent messages, the pending digest and any injected latency or failures
+func (s *MockSlackService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = make([]SlackMessage, 0)
+	s.digest = nil
+	s.latency = 0
+	s.failure = nil
+	s.failNext, s.failNextErr = 0, nil
 }
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+// newTestSlack returns a mock Slack service whose digest reads from a feedback service
+// moderated by checks. Praise goes to the digest; everything else to #feedback.
+func newTestSlack(t *testing.T, checks ...ModerationCheck) (*MockSlackService, *FeedbackService) {
+	t.Helper()
+	redactor := newTestRedactor(t)
+	routing, err := SlackRoutingFromConfig(config.SlackConfig{
+		Routes:         "category=praise => digest",
+		DefaultChannel: "#feedback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedbackService, _ := newTestFeedbackService(checks...)
+	return NewMockSlackService(redactor, routing, feedbackService.GetFeedback, newTestLogger()), feedbackService
+}
+
+func TestSendDigestUsesCurrentFeedback(t *testing.T) {
+	slack, feedbackService := newTestSlack(t, NewBlockedWordsCheck([]string{"spam"}))
+
+	submit := func(content string) *models.Feedback {
+		fb, err := feedbackService.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: content, Platform: "ios", Category: "praise"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := slack.PublishFeedback(context.Background(), fb); err != nil {
+			t.Fatal(err)
+		}
+		return fb
+	}
+	withAttachments := submit("Love the new screens")
+	edited := submit("Great app")
+	heldLater := submit("Nice colours")
+	deleted := submit("Fast and simple")
+
+	for _, id := range []string{"attachment-1", "attachment-2"} {
+		if err := feedbackService.AddAttachment(&models.Attachment{ID: id, FeedbackID: withAttachments.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := feedbackService.UpdateFeedback("user-1", edited.ID, models.UpdateFeedbackRequest{Content: strPtr("Great app, write me at jane@example.com")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feedbackService.UpdateFeedback("user-1", heldLater.ID, models.UpdateFeedbackRequest{Content: strPtr("Buy spam")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feedbackService.DeleteFeedback("user-1", deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := slack.SendDigest(context.Background())
+	if err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+	if sent != 2 {
+		t.Errorf("digest had %d entries, want 2", sent)
+	}
+
+	messages := slack.GetMessages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want the digest only", len(messages))
+	}
+	text := messages[0].Text
+	if !strings.Contains(text, "praise 📎2: Love the new screens") {
+		t.Errorf("digest doesn't show the attachment count:\n%s", text)
+	}
+	if !strings.Contains(text, "Great app, write me at [email]") {
+		t.Errorf("digest doesn't show the redacted edit:\n%s", text)
+	}
+	if strings.Contains(text, heldLater.ID) || strings.Contains(text, deleted.ID) {
+		t.Errorf("digest includes held or deleted feedback:\n%s", text)
+	}
+}
+
+func TestSendDigestKeepsEntriesWhenSendingFails(t *testing.T) {
+	slack, feedbackService := newTestSlack(t)
+	fb, err := feedbackService.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "Love it", Platform: "ios", Category: "praise"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slack.PublishFeedback(context.Background(), fb)
+
+	slack.FailNext(1, nil)
+	if _, err := slack.SendDigest(context.Background()); err != ErrMockSlackFailure {
+		t.Fatalf("SendDigest = %v, want ErrMockSlackFailure", err)
+	}
+	if sent, err := slack.SendDigest(context.Background()); err != nil || sent != 1 {
+		t.Errorf("retried SendDigest = %d, %v; want the kept entry", sent, err)
+	}
+	if _, err := slack.WaitForMessages(1, time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSendDigestGivesUpAtTheDeadline(t *testing.T) {
+	slack, feedbackService := newTestSlack(t)
+	fb, err := feedbackService.StoreFeedback("user-1", "user@example.com", models.SubmitFeedbackRequest{Content: "Love it", Platform: "ios", Category: "praise"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slack.PublishFeedback(context.Background(), fb)
+	if n := slack.PendingDigest(); n != 1 {
+		t.Fatalf("PendingDigest = %d, want 1", n)
+	}
+
+	// A shutdown flush against a slow Slack must not outlive the drain deadline
+	slack.SetLatency(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := slack.SendDigest(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("SendDigest = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SendDigest took %s after the deadline", elapsed)
+	}
+	if n := slack.PendingDigest(); n != 1 {
+		t.Errorf("PendingDigest = %d after a failed flush, want 1 so the drop can be logged", n)
+	}
+
+	slack.SetLatency(0)
+	if sent, err := slack.SendDigest(context.Background()); err != nil || sent != 1 {
+		t.Errorf("SendDigest = %d, %v; want the pending entry", sent, err)
+	}
+	if n := slack.PendingDigest(); n != 0 {
+		t.Errorf("PendingDigest = %d after sending, want 0", n)
+	}
+}
+
+func TestMockSlackInjectedFailures(t *testing.T) {
+	slack, _ := newTestSlack(t)
+	fb := &models.Feedback{ID: "fb-1", Content: "Works", Platform: "ios"}
+
+	slack.FailNext(2, nil)
+	for i := 0; i < 2; i++ {
+		if err := slack.PublishFeedback(context.Background(), fb); err != ErrMockSlackFailure {
+			t.Errorf("send %d = %v, want ErrMockSlackFailure", i+1, err)
+		}
+	}
+	if err := slack.PublishFeedback(context.Background(), fb); err != nil {
+		t.Errorf("send after FailNext ran out = %v", err)
+	}
+
+	outage := errors.New("slack is down")
+	slack.SetFailure(outage)
+	for i := 0; i < 3; i++ {
+		if err := slack.PublishFeedback(context.Background(), fb); err != outage {
+			t.Errorf("send during outage = %v, want the injected error", err)
+		}
+	}
+	slack.SetFailure(nil)
+	if err := slack.PublishFeedback(context.Background(), fb); err != nil {
+		t.Errorf("send after the outage = %v", err)
+	}
+
+	if got := len(slack.GetMessages()); got != 2 {
+		t.Errorf("%d messages recorded, want only the 2 successful sends", got)
+	}
+}
+
+func TestMockSlackGetMessagesReturnsCopy(t *testing.T) {
+	slack, _ := newTestSlack(t)
+	if err := slack.PublishFeedback(context.Background(), &models.Feedback{ID: "fb-1", Content: "Works", Platform: "ios"}); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := slack.GetMessages()
+	messages[0].Text = "changed"
+
+	again := slack.GetMessages()
+	if len(again) != 1 || again[0].Text == "changed" {
+		t.Errorf("stored messages were changed through a returned slice: %v", again)
+	}
+}
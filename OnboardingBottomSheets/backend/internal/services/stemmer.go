@@ -0,0 +1,233 @@
+package services
+
+import (
+	"sort"
+	"strings"
+)
+
+// stem reduces an English word to its stem with the Porter algorithm, so "crashes",
+// "crashed" and "crashing" are all indexed as "crash". Words that aren't plain
+// lowercase ASCII letters are returned unchanged.
+func stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &porterStemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.replaceSuffix(step2Suffixes, 0)
+	s.replaceSuffix(step3Suffixes, 0)
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+// porterStemmer holds the word being stemmed
+type porterStemmer struct {
+	b []byte
+}
+
+// cons reports whether the letter at i is a consonant. "y" is a consonant at the
+// start of a word or after a vowel.
+func (s *porterStemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in the first j letters
+func (s *porterStemmer) measure(j int) int {
+	n, i := 0, 0
+	for i < j && s.cons(i) {
+		i++
+	}
+	for i < j {
+		for i < j && !s.cons(i) {
+			i++
+		}
+		if i >= j {
+			break
+		}
+		for i < j && s.cons(i) {
+			i++
+		}
+		n++
+	}
+	return n
+}
+
+// vowelInStem reports whether the first j letters contain a vowel
+func (s *porterStemmer) vowelInStem(j int) bool {
+	for i := 0; i < j; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleCons reports whether the first j letters end in a double consonant
+func (s *porterStemmer) doubleCons(j int) bool {
+	return j >= 2 && s.b[j-1] == s.b[j-2] && s.cons(j-1)
+}
+
+// cvc reports whether the first j letters end consonant-vowel-consonant, where the
+// last consonant is not w, x or y (as in "hop" but not "snow")
+func (s *porterStemmer) cvc(j int) bool {
+	if j < 3 || !s.cons(j-1) || s.cons(j-2) || !s.cons(j-3) {
+		return false
+	}
+	c := s.b[j-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+// ends reports whether the word ends in suffix
+func (s *porterStemmer) ends(suffix string) bool {
+	return strings.HasSuffix(string(s.b), suffix)
+}
+
+// setEnd replaces the last n letters with repl
+func (s *porterStemmer) setEnd(n int, repl string) {
+	s.b = append(s.b[:len(s.b)-n], repl...)
+}
+
+// step1a removes plurals: caresses -> caress, ponies -> poni, cats -> cat
+func (s *porterStemmer) step1a() {
+	switch {
+	case s.ends("sses"):
+		s.setEnd(2, "")
+	case s.ends("ies"):
+		s.setEnd(2, "")
+	case s.ends("ss"):
+	case s.ends("s"):
+		s.setEnd(1, "")
+	}
+}
+
+// step1b removes -ed and -ing: agreed -> agree, hopping -> hop, filing -> file
+func (s *porterStemmer) step1b() {
+	if s.ends("eed") {
+		if s.measure(len(s.b)-3) > 0 {
+			s.setEnd(1, "")
+		}
+		return
+	}
+
+	var n int
+	switch {
+	case s.ends("ed"):
+		n = 2
+	case s.ends("ing"):
+		n = 3
+	default:
+		return
+	}
+	if !s.vowelInStem(len(s.b) - n) {
+		return
+	}
+	s.setEnd(n, "")
+
+	j := len(s.b)
+	switch {
+	case s.ends("at"), s.ends("bl"), s.ends("iz"):
+		s.setEnd(0, "e")
+	case s.doubleCons(j) && s.b[j-1] != 'l' && s.b[j-1] != 's' && s.b[j-1] != 'z':
+		s.setEnd(1, "")
+	case s.measure(j) == 1 && s.cvc(j):
+		s.setEnd(0, "e")
+	}
+}
+
+// step1c turns a final y into i when the stem has a vowel: happy -> happi
+func (s *porterStemmer) step1c() {
+	if s.ends("y") && s.vowelInStem(len(s.b)-1) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+// suffixRule replaces a suffix when the remaining stem is long enough
+type suffixRule struct {
+	suffix, repl string
+}
+
+// longestFirst sorts rules so the longest matching suffix is found first
+func longestFirst(rules []suffixRule) []suffixRule {
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].suffix) > len(rules[j].suffix) })
+	return rules
+}
+
+var step2Suffixes = longestFirst([]suffixRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+})
+
+var step3Suffixes = longestFirst([]suffixRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+})
+
+var step4Suffixes = longestFirst([]suffixRule{
+	{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""},
+	{"able", ""}, {"ible", ""}, {"ant", ""}, {"ement", ""}, {"ment", ""},
+	{"ent", ""}, {"ion", ""}, {"ou", ""}, {"ism", ""}, {"ate", ""},
+	{"iti", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""},
+})
+
+// replaceSuffix applies the first rule whose suffix matches, if the stem before it
+// has a measure above minMeasure
+func (s *porterStemmer) replaceSuffix(rules []suffixRule, minMeasure int) {
+	for _, rule := range rules {
+		if s.ends(rule.suffix) {
+			if s.measure(len(s.b)-len(rule.suffix)) > minMeasure {
+				s.setEnd(len(rule.suffix), rule.repl)
+			}
+			return
+		}
+	}
+}
+
+// step4 removes suffixes from longer stems: revival -> reviv, adoption -> adopt
+func (s *porterStemmer) step4() {
+	for _, rule := range step4Suffixes {
+		if !s.ends(rule.suffix) {
+			continue
+		}
+		j := len(s.b) - len(rule.suffix)
+		if rule.suffix == "ion" && (j == 0 || (s.b[j-1] != 's' && s.b[j-1] != 't')) {
+			return
+		}
+		if s.measure(j) > 1 {
+			s.setEnd(len(rule.suffix), "")
+		}
+		return
+	}
+}
+
+// step5 removes a final e and reduces a final ll: probate -> probat, controll -> control
+func (s *porterStemmer) step5() {
+	j := len(s.b)
+	if s.b[j-1] == 'e' {
+		if m := s.measure(j - 1); m > 1 || (m == 1 && !s.cvc(j-1)) {
+			s.setEnd(1, "")
+		}
+	}
+	j = len(s.b)
+	if j > 1 && s.b[j-1] == 'l' && s.doubleCons(j) && s.measure(j) > 1 {
+		s.setEnd(1, "")
+	}
+}
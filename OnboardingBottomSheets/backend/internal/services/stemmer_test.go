@@ -0,0 +1,46 @@
+package services
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Plurals
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"cats", "cat"},
+		{"caress", "caress"},
+		// -ed and -ing
+		{"agreed", "agre"},
+		{"feed", "feed"},
+		{"hopping", "hop"},
+		{"filing", "file"},
+		{"falling", "fall"},
+		{"sing", "sing"},
+		{"crashes", "crash"},
+		{"crashed", "crash"},
+		{"crashing", "crash"},
+		// Final y
+		{"happy", "happi"},
+		{"sky", "sky"},
+		// Longer suffixes
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"generalization", "gener"},
+		{"hopefulness", "hope"},
+		{"adoption", "adopt"},
+		{"controlling", "control"},
+		// Left alone
+		{"is", "is"},
+		{"ios17", "ios17"},
+		{"café", "café"},
+	}
+
+	for _, tt := range tests {
+		if got := stem(tt.word); got != tt.want {
+			t.Errorf("stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+var (
+	ErrTicketingDisabled = errors.New("ticketing is not configured")
+	ErrTicketExists      = errors.New("feedback already has a ticket")
+	ErrTicketInProgress  = errors.New("a ticket is already being created for this feedback")
+)
+
+const (
+	// ticketTimeout bounds a single request to the tracker
+	ticketTimeout = 15 * time.Second
+
+	// ticketTitleLength caps how much of the feedback is used as the issue title
+	ticketTitleLength = 80
+)
+
+// TicketSink creates issues in an external tracker
+type TicketSink interface {
+	// CreateTicket files an issue for the feedback and returns the issue's URL
+	CreateTicket(feedback *models.Feedback) (string, error)
+}
+
+// GitHubTicketSink creates issues through a GitHub-Issues-compatible REST API
+type GitHubTicketSink struct {
+	apiURL string // e.g. https://api.github.com
+	repo   string // owner/name
+	token  string
+	labels []string
+	client *http.Client
+}
+
+// NewGitHubTicketSink creates a sink that files issues in repo through the API at apiURL
+func NewGitHubTicketSink(apiURL, repo, token string, labels []string) *GitHubTicketSink {
+	return &GitHubTicketSink{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		repo:   repo,
+		token:  token,
+		labels: labels,
+		client: &http.Client{Timeout: ticketTimeout},
+	}
+}
+
+// githubIssueRequest is the body of a create-issue request
+type githubIssueRequest struct {
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// CreateTicket files an issue and returns its html_url
+func (s *GitHubTicketSink) CreateTicket(feedback *models.Feedback) (string, error) {
+	body, err := json.Marshal(githubIssueRequest{
+		Title:  ticketTitle(feedback),
+		Body:   ticketBody(feedback),
+		Labels: s.labels,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.apiURL+"/repos/"+s.repo+"/issues", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("tracker returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var issue struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return "", fmt.Errorf("invalid tracker response: %w", err)
+	}
+	if issue.HTMLURL == "" {
+		return "", errors.New("tracker response has no html_url")
+	}
+	return issue.HTMLURL, nil
+}
+
+// ticketTitle uses the first line of the feedback as the issue title
+func ticketTitle(feedback *models.Feedback) string {
+	line, _, _ := strings.Cut(feedback.Content, "\n")
+	title := excerpt(strings.TrimSpace(line), ticketTitleLength)
+	if feedback.Category != "" {
+		title = "[" + feedback.Category + "] " + title
+	}
+	return title
+}
+
+// ticketBody renders the feedback as a Markdown issue description
+func ticketBody(feedback *models.Feedback) string {
+	var b strings.Builder
+	b.WriteString(feedback.Content)
+	b.WriteString("\n\n---\n\n")
+	fmt.Fprintf(&b, "- **Feedback ID:** %s\n", feedback.ID)
+	fmt.Fprintf(&b, "- **User ID:** %s\n", feedback.UserID)
+	fmt.Fprintf(&b, "- **Platform:** %s\n", feedback.Platform)
+	if feedback.Rating > 0 {
+		fmt.Fprintf(&b, "- **Rating:** %d/5\n", feedback.Rating)
+	}
+	if feedback.Screen != "" {
+		fmt.Fprintf(&b, "- **Screen:** %s\n", feedback.Screen)
+	}
+	if len(feedback.Tags) > 0 {
+		fmt.Fprintf(&b, "- **Tags:** %s\n", strings.Join(feedback.Tags, ", "))
+	}
+	if len(feedback.Attachments) > 0 {
+		fmt.Fprintf(&b, "- **Attachments:** %d\n", len(feedback.Attachments))
+	}
+	fmt.Fprintf(&b, "- **Submitted:** %s\n", feedback.CreatedAt.UTC().Format(time.RFC3339))
+	return b.String()
+}
+
+// ParseTicketRules parses feedback rules separated by ";", e.g.
+// "category=bug;tag=crash,platform=ios" (see ParseFeedbackRule)
+func ParseTicketRules(spec string) ([]FeedbackRule, error) {
+	var rules []FeedbackRule
+	for _, ruleSpec := range strings.Split(spec, ";") {
+		if strings.TrimSpace(ruleSpec) == "" {
+			continue
+		}
+		rule, err := ParseFeedbackRule(ruleSpec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// TicketService turns feedback matching the configured rules into tracker issues and
+// records the issue URL on the feedback
+type TicketService struct {
+	sink            TicketSink // nil when ticketing is disabled
+	rules           []FeedbackRule
+	feedbackService *FeedbackService
+	redactor        *Redactor
+	inFlight        map[string]bool // feedbackID -> ticket being created
+	logger          *slog.Logger
+	mu              sync.Mutex
+}
+
+// NewTicketService creates a ticket service. Personal data is removed from issues with
+// redactor. A nil sink disables ticketing.
+func NewTicketService(sink TicketSink, rules []FeedbackRule, feedbackService *FeedbackService, redactor *Redactor, logger *slog.Logger) *TicketService {
+	return &TicketService{
+		sink:            sink,
+		rules:           rules,
+		feedbackService: feedbackService,
+		redactor:        redactor,
+		inFlight:        make(map[string]bool),
+		logger:          logger,
+	}
+}
+
+// NewTicketServiceFromConfig creates a ticket service that files GitHub-compatible issues
+// in the configured repo (owner/name). Ticketing is disabled when no repo is set.
+func NewTicketServiceFromConfig(cfg config.TicketsConfig, feedbackService *FeedbackService, redactor *Redactor, logger *slog.Logger) (*TicketService, error) {
+	rules, err := ParseTicketRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var sink TicketSink
+	if cfg.Repo != "" {
+		sink = NewGitHubTicketSink(cfg.APIURL, cfg.Repo, cfg.APIToken, cfg.Labels)
+	}
+	return NewTicketService(sink, rules, feedbackService, redactor, logger), nil
+}
+
+// Matches reports whether feedback meets any of the configured rules
+func (s *TicketService) Matches(feedback *models.Feedback) bool {
+	for _, rule := range s.rules {
+		if rule.Matches(feedback) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileIfMatched creates a ticket for approved feedback that matches a rule. Failures
+// are logged; an admin can retry with CreateTicket.
+func (s *TicketService) FileIfMatched(ctx context.Context, feedback *models.Feedback) {
+	if s.sink == nil || feedback.ModerationStatus != models.ModerationApproved || !s.Matches(feedback) {
+		return
+	}
+	if _, err := s.CreateTicket(feedback.ID); err != nil && err != ErrTicketExists && err != ErrTicketInProgress {
+		s.logger.ErrorContext(ctx, "failed to create ticket", "feedback_id", feedback.ID, "error", err)
+	}
+}
+
+// CreateTicket files a ticket for feedback regardless of the rules and returns the
+// feedback with its ticket URL
+func (s *TicketService) CreateTicket(feedbackID string) (*models.Feedback, error) {
+	if s.sink == nil {
+		return nil, ErrTicketingDisabled
+	}
+
+	// Only one ticket per feedback, even if rules and an admin race
+	s.mu.Lock()
+	if s.inFlight[feedbackID] {
+		s.mu.Unlock()
+		return nil, ErrTicketInProgress
+	}
+	s.inFlight[feedbackID] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.inFlight, feedbackID)
+		s.mu.Unlock()
+	}()
+
+	feedback, exists := s.feedbackService.GetFeedback(feedbackID)
+	if !exists {
+		return nil, ErrFeedbackNotFound
+	}
+	if feedback.TicketURL != "" {
+		return nil, ErrTicketExists
+	}
+
+	url, err := s.sink.CreateTicket(s.redactor.RedactFeedback(feedback))
+	if err != nil {
+		return nil, err
+	}
+	return s.feedbackService.SetTicketURL(feedbackID, url)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"onboarding-backend/internal/models"
+)
+
+// fakeTracker is a GitHub-Issues-compatible API that records the issues it receives
+type fakeTracker struct {
+	status   int
+	response string
+	requests []*http.Request
+	issues   []githubIssueRequest
+	mu       sync.Mutex
+}
+
+func (f *fakeTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var issue githubIssueRequest
+	json.NewDecoder(r.Body).Decode(&issue)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, r)
+	f.issues = append(f.issues, issue)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.status)
+	w.Write([]byte(f.response))
+}
+
+func newFakeTracker(t *testing.T, status int, response string) (*fakeTracker, *httptest.Server) {
+	t.Helper()
+	tracker := &fakeTracker{status: status, response: response}
+	server := httptest.NewServer(tracker)
+	t.Cleanup(server.Close)
+	return tracker, server
+}
+
+func TestGitHubTicketSinkCreatesIssue(t *testing.T) {
+	tracker, server := newFakeTracker(t, http.StatusCreated, `{"number": 7, "html_url": "https://github.example/acme/app/issues/7"}`)
+	sink := NewGitHubTicketSink(server.URL+"/", "acme/app", "secret-token", []string{"feedback", "triage"})
+
+	url, err := sink.CreateTicket(&models.Feedback{ID: "fb-1", Content: "App crashes\non launch", Category: "bug", Platform: "ios"})
+	if err != nil {
+		t.Fatalf("CreateTicket: %v", err)
+	}
+	if url != "https://github.example/acme/app/issues/7" {
+		t.Errorf("url = %q, want the issue's html_url", url)
+	}
+
+	if len(tracker.requests) != 1 {
+		t.Fatalf("tracker got %d requests, want 1", len(tracker.requests))
+	}
+	req, issue := tracker.requests[0], tracker.issues[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/repos/acme/app/issues" {
+		t.Errorf("request = %s %s, want POST /repos/acme/app/issues", req.Method, req.URL.Path)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer secret-token" {
+		t.Errorf("Authorization = %q, want the bearer token", got)
+	}
+	if strings.Join(issue.Labels, ",") != "feedback,triage" {
+		t.Errorf("labels = %v, want [feedback triage]", issue.Labels)
+	}
+	if issue.Title != "[bug] App crashes" || !strings.Contains(issue.Body, "**Feedback ID:** fb-1") {
+		t.Errorf("issue = %+v, want the feedback's title and body", issue)
+	}
+}
+
+func TestGitHubTicketSinkErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		want     string
+	}{
+		{"non-2xx", http.StatusUnprocessableEntity, `{"message": "Validation Failed"}`, `tracker returned 422: {"message": "Validation Failed"}`},
+		{"missing html_url", http.StatusCreated, `{"number": 7}`, "tracker response has no html_url"},
+		{"invalid json", http.StatusCreated, `not json`, "invalid tracker response"},
+	}
+
+	for _, tt := range tests {
+		_, server := newFakeTracker(t, tt.status, tt.response)
+		sink := NewGitHubTicketSink(server.URL, "acme/app", "", nil)
+
+		_, err := sink.CreateTicket(&models.Feedback{ID: "fb-1", Content: "Crash"})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestTicketServiceRecordsTicketURL(t *testing.T) {
+	tracker, server := newFakeTracker(t, http.StatusCreated, `{"html_url": "https://github.example/acme/app/issues/1"}`)
+	feedbackService, _ := newTestFeedbackService()
+	tickets := NewTicketService(NewGitHubTicketSink(server.URL, "acme/app", "", nil), nil, feedbackService, newTestRedactor(t), newTestLogger())
+
+	fb, err := feedbackService.StoreFeedback("user-1", "jane@example.com", models.SubmitFeedbackRequest{Content: "Mail jane@example.com", Platform: "ios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticketed, err := tickets.CreateTicket(fb.ID)
+	if err != nil {
+		t.Fatalf("CreateTicket: %v", err)
+	}
+	if ticketed.TicketURL != "https://github.example/acme/app/issues/1" {
+		t.Errorf("returned ticket URL = %q", ticketed.TicketURL)
+	}
+	if stored, _ := feedbackService.GetFeedback(fb.ID); stored.TicketURL != ticketed.TicketURL {
+		t.Errorf("stored ticket URL = %q, want it recorded", stored.TicketURL)
+	}
+	if strings.Contains(tracker.issues[0].Body, "jane@example.com") {
+		t.Error("issue body contains the user's email")
+	}
+
+	if _, err := tickets.CreateTicket(fb.ID); err != ErrTicketExists {
+		t.Errorf("second CreateTicket = %v, want ErrTicketExists", err)
+	}
+	if len(tracker.requests) != 1 {
+		t.Errorf("tracker got %d requests, want 1", len(tracker.requests))
+	}
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrInvalidLocale   = errors.New("invalid locale")
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	ErrEmailInUse      = errors.New("email address is already in use")
+	ErrEmailUnchanged  = errors.New("email address is unchanged")
+	ErrInvalidRole     = errors.New("invalid role")
+)
+
+// UserService handles user accounts and profiles
+type UserService struct {
+	users        map[string]*models.User        // userID -> user
+	emails       map[string]string              // normalized email -> userID
+	emailChanges map[string]*models.EmailChange // token -> pending change
+	bootstrap    map[string]string              // normalized email -> role granted at login
+	emailService *EmailService
+	events       EventPublisher
+	baseURL      string
+	changeTTL    time.Duration // how long the verification link for a new address stays valid
+	logger       *slog.Logger
+	mu           sync.RWMutex
+}
+
+// NewUserService creates a new user service. Users listed in the configured admin and
+// support emails are granted those roles when they sign in. Sign-ups and completed
+// onboarding are published to events, and verification links point at baseURL and
+// expire after the configured email change TTL.
+func NewUserService(emailService *EmailService, events EventPublisher, roles config.RolesConfig, accounts config.AccountsConfig, baseURL string, logger *slog.Logger) *UserService {
+	bootstrap := make(map[string]string)
+	for _, email := range roles.SupportEmails {
+		if email = normalizeEmail(email); email != "" {
+			bootstrap[email] = models.RoleSupport
+		}
+	}
+	for _, email := range roles.AdminEmails {
+		if email = normalizeEmail(email); email != "" {
+			bootstrap[email] = models.RoleAdmin
+		}
+	}
+
+	return &UserService{
+		users:        make(map[string]*models.User),
+		emails:       make(map[string]string),
+		emailChanges: make(map[string]*models.EmailChange),
+		bootstrap:    bootstrap,
+		emailService: emailService,
+		events:       events,
+		baseURL:      baseURL,
+		changeTTL:    accounts.EmailChangeTTL,
+		logger:       logger,
+	}
+}
+
+// roleRank orders roles by privilege
+func roleRank(role string) int {
+	switch role {
+	case models.RoleAdmin:
+		return 2
+	case models.RoleSupport:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// normalizeEmail returns the form of an address used for lookups
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// GetOrCreateUser returns a snapshot of the user with the given email, creating it if needed
+func (s *UserService) GetOrCreateUser(email string) *models.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if userID, exists := s.emails[normalizeEmail(email)]; exists {
+		snapshot := *s.users[userID]
+		return &snapshot
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:        uuid.New().String(),
+		Email:     email,
+		Role:      models.RoleUser,
+		CreatedAt: now,
+		UpdatedAt: now,
+		IsNewUser: true,
+	}
+	s.users[user.ID] = user
+	s.emails[normalizeEmail(email)] = user.ID
+
+	snapshot := *user
+	s.events.Publish(EventUserCreated, &snapshot)
+	return &snapshot
+}
+
+// RecordLogin updates a user's login tracking and returns a snapshot of the user.
+// Whether the user is new is derived from onboarding state, not from the login count,
+// so the welcome flow keeps showing until the user has actually finished it.
+func (s *UserService) RecordLogin(userID string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	now := time.Now()
+	if user.FirstLoginAt == nil {
+		user.FirstLoginAt = &now
+	}
+	user.LastLoginAt = &now
+	user.LoginCount++
+
+	// Configured roles are only ever granted here, never taken away
+	if role, ok := s.bootstrap[normalizeEmail(user.Email)]; ok && roleRank(role) > roleRank(user.Role) {
+		user.Role = role
+	}
+	user.IsNewUser = user.OnboardingCompletedAt == nil
+
+	snapshot := *user
+	return &snapshot, nil
+}
+
+// CompleteOnboarding records that the user finished the onboarding flow.
+// Completing it again keeps the original completion time.
+func (s *UserService) CompleteOnboarding(userID string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	completed := user.OnboardingCompletedAt == nil
+	if completed {
+		now := time.Now()
+		user.OnboardingCompletedAt = &now
+		user.UpdatedAt = now
+	}
+	user.IsNewUser = false
+
+	snapshot := *user
+	if completed {
+		s.events.Publish(EventOnboardingCompleted, &snapshot)
+	}
+	return &snapshot, nil
+}
+
+// GetUserByID returns a snapshot of a user by ID
+func (s *UserService) GetUserByID(userID string) (*models.User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, false
+	}
+	snapshot := *user
+	return &snapshot, true
+}
+
+// GetUserByEmail returns a snapshot of a user by email
+func (s *UserService) GetUserByEmail(email string) (*models.User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userID, exists := s.emails[normalizeEmail(email)]
+	if !exists {
+		return nil, false
+	}
+	snapshot := *s.users[userID]
+	return &snapshot, true
+}
+
+// GetProfile returns a snapshot of a user's profile
+func (s *UserService) GetProfile(userID string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	profile := *user
+	return &profile, nil
+}
+
+// UpdateProfile applies the non-nil fields of req to a user's profile
+func (s *UserService) UpdateProfile(userID string, req models.UpdateProfileRequest) (*models.User, error) {
+	// Validate before taking the lock so a bad request never partially applies
+	var locale string
+	if req.Locale != nil && *req.Locale != "" {
+		tag, err := language.Parse(*req.Locale)
+		if err != nil {
+			return nil, ErrInvalidLocale
+		}
+		locale = tag.String()
+	}
+	if req.Timezone != nil && *req.Timezone != "" {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, ErrInvalidTimezone
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	now := time.Now()
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Locale != nil {
+		user.Locale = locale
+	}
+	if req.Timezone != nil {
+		user.Timezone = *req.Timezone
+	}
+	if req.MarketingConsent != nil && *req.MarketingConsent != user.MarketingConsent {
+		user.MarketingConsent = *req.MarketingConsent
+		user.MarketingConsentAt = &now
+	}
+	user.UpdatedAt = now
+
+	profile := *user
+	return &profile, nil
+}
+
+// SetRole changes a user's role. Tokens are checked against the current role, so the
+// change applies from the user's next request.
+func (s *UserService) SetRole(userID, role string) (*models.User, error) {
+	switch role {
+	case models.RoleUser, models.RoleSupport, models.RoleAdmin:
+	default:
+		return nil, ErrInvalidRole
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+
+	snapshot := *user
+	return &snapshot, nil
+}
+
+// RequestEmailChange sends a verification link to the new address. The user's
+// email is only replaced once that link is confirmed.
+func (s *UserService) RequestEmailChange(ctx context.Context, userID, newEmail string) error {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	s.mu.Lock()
+	user, exists := s.users[userID]
+	if !exists {
+		s.mu.Unlock()
+		return ErrUserNotFound
+	}
+	if normalizeEmail(newEmail) == normalizeEmail(user.Email) {
+		s.mu.Unlock()
+		return ErrEmailUnchanged
+	}
+	if _, taken := s.emails[normalizeEmail(newEmail)]; taken {
+		s.mu.Unlock()
+		return ErrEmailInUse
+	}
+
+	// Only the most recent request for a user stays valid
+	for t, change := range s.emailChanges {
+		if change.UserID == userID {
+			delete(s.emailChanges, t)
+		}
+	}
+	s.emailChanges[token] = &models.EmailChange{
+		Token:     token,
+		UserID:    userID,
+		NewEmail:  newEmail,
+		ExpiresAt: time.Now().Add(s.changeTTL),
+		CreatedAt: time.Now(),
+	}
+	user.PendingEmail = newEmail
+	s.mu.Unlock()
+
+	link := fmt.Sprintf("%s/auth/confirm-email?token=%s", s.baseURL, token)
+	return s.emailService.SendEmailChangeVerification(ctx, newEmail, link)
+}
+
+// ConfirmEmailChange replaces the user's email with the verified new address
+// and notifies the old address
+func (s *UserService) ConfirmEmailChange(ctx context.Context, token string) (*models.User, error) {
+	s.mu.Lock()
+	change, exists := s.emailChanges[token]
+	if !exists {
+		s.mu.Unlock()
+		return nil, ErrInvalidToken
+	}
+	delete(s.emailChanges, token)
+
+	if time.Now().After(change.ExpiresAt) {
+		s.mu.Unlock()
+		return nil, ErrInvalidToken
+	}
+
+	user, exists := s.users[change.UserID]
+	if !exists {
+		s.mu.Unlock()
+		return nil, ErrUserNotFound
+	}
+	// The address may have been claimed since the change was requested
+	if _, taken := s.emails[normalizeEmail(change.NewEmail)]; taken {
+		s.mu.Unlock()
+		return nil, ErrEmailInUse
+	}
+
+	oldEmail := user.Email
+	delete(s.emails, normalizeEmail(oldEmail))
+	s.emails[normalizeEmail(change.NewEmail)] = user.ID
+	user.Email = change.NewEmail
+	user.PendingEmail = ""
+	user.UpdatedAt = time.Now()
+	profile := *user
+	s.mu.Unlock()
+
+	if err := s.emailService.SendEmailChangedNotice(ctx, oldEmail, change.NewEmail); err != nil {
+		s.logger.WarnContext(ctx, "failed to send email change notice", "user_id", change.UserID, "to", oldEmail, "error", err)
+	}
+
+	return &profile, nil
+}
+
+// ScheduleDeletion marks a user's account for deletion at the given time
+func (s *UserService) ScheduleDeletion(userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	user.DeletionScheduledFor = &at
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// CancelDeletion clears a scheduled account deletion and reports whether one was pending
+func (s *UserService) CancelDeletion(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || user.DeletionScheduledFor == nil {
+		return false
+	}
+	user.DeletionScheduledFor = nil
+	user.UpdatedAt = time.Now()
+	return true
+}
+
+// GetUsersDueForDeletion returns the IDs of users whose deletion grace period has ended
+func (s *UserService) GetUsersDueForDeletion(now time.Time) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []string
+	for _, user := range s.users {
+		if user.DeletionScheduledFor != nil && !now.Before(*user.DeletionScheduledFor) {
+			result = append(result, user.ID)
+		}
+	}
+	return result
+}
+
+// GetUsersCreatedBetween returns the IDs of users who signed up in [from, to)
+func (s *UserService) GetUsersCreatedBetween(from, to time.Time) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []string
+	for _, user := range s.users {
+		if !user.CreatedAt.Before(from) && user.CreatedAt.Before(to) {
+			result = append(result, user.ID)
+		}
+	}
+	return result
+}
+
+// DeleteUser removes a user and their pending email changes, returning the removed user
+func (s *UserService) DeleteUser(userID string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	delete(s.users, userID)
+	delete(s.emails, normalizeEmail(user.Email))
+	for token, change := range s.emailChanges {
+		if change.UserID == userID {
+			delete(s.emailChanges, token)
+		}
+	}
+	return user, nil
+}
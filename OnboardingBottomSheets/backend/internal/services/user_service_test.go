@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/logging"
+	"onboarding-backend/internal/models"
+)
+
+func TestUserLookupsReturnSnapshots(t *testing.T) {
+	s := newTestUserService()
+	created := s.GetOrCreateUser("user@example.com")
+	created.Role = models.RoleAdmin
+
+	byID, _ := s.GetUserByID(created.ID)
+	byID.Role = models.RoleAdmin
+	byEmail, _ := s.GetUserByEmail("USER@example.com")
+	byEmail.Email = "other@example.com"
+	again := s.GetOrCreateUser("user@example.com")
+	again.DisplayName = "Changed"
+
+	stored, exists := s.GetUserByID(created.ID)
+	if !exists {
+		t.Fatal("user not found")
+	}
+	if stored.Role != models.RoleUser || stored.Email != "user@example.com" || stored.DisplayName != "" {
+		t.Errorf("stored user was changed through a snapshot: %+v", stored)
+	}
+}
+
+func TestUserLookupsDoNotRaceWithUpdates(t *testing.T) {
+	s := newTestUserService()
+	user := s.GetOrCreateUser("user@example.com")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := s.RecordLogin(user.ID); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if u, ok := s.GetUserByID(user.ID); ok {
+					_ = u.LoginCount
+				}
+				if u, ok := s.GetUserByEmail(user.Email); ok {
+					_ = u.LastLoginAt
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, _ := s.GetUserByID(user.ID); got.LoginCount != 400 {
+		t.Errorf("login count = %d, want 400", got.LoginCount)
+	}
+}
+
+func TestEmailChangeLogsCarryRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	logger := logging.New(&logs, config.LoggingConfig{Level: "info", Format: config.LogFormatText}, nil)
+	_, s := newTestUsers(logger, config.RolesConfig{})
+	user := s.GetOrCreateUser("user@example.com")
+
+	ctx := logging.WithRequestID(context.Background(), "req-42")
+	if err := s.RequestEmailChange(ctx, user.ID, "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "email change confirmation not sent") || !strings.Contains(logs.String(), "request_id=req-42") {
+		t.Errorf("email log is missing the request ID:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute https URL")
+	ErrUnknownWebhookEvent     = errors.New("unknown webhook event")
+)
+
+// Event types sent to webhook subscribers
+const (
+	EventFeedbackCreated     = "feedback.created"
+	EventUserCreated         = "user.created"
+	EventOnboardingCompleted = "onboarding.completed"
+
+	// webhookAllEvents subscribes to every event type
+	webhookAllEvents = "*"
+)
+
+// WebhookEvents lists the event types a subscription can ask for
+var WebhookEvents = []string{EventFeedbackCreated, EventUserCreated, EventOnboardingCompleted}
+
+const (
+	// WebhookSignatureHeader carries "t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	// webhookTimeout bounds a single delivery attempt
+	webhookTimeout = 10 * time.Second
+
+	// maxWebhookDeliveries is how many deliveries the log keeps, oldest dropped first
+	maxWebhookDeliveries = 1000
+
+	// webhookResponseExcerpt is how much of a response body is kept with an attempt
+	webhookResponseExcerpt = 512
+)
+
+// webhookRetryDelays is the wait before each retry of a failed delivery
+var webhookRetryDelays = []time.Duration{
+	30 * time.Second, 2 * time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour,
+}
+
+// EventPublisher notifies integrations that something happened
+type EventPublisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// webhookUser is the user data sent with user events
+type webhookUser struct {
+	ID                    string     `json:"id"`
+	Email                 string     `json:"email"`
+	Locale                string     `json:"locale,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	OnboardingCompletedAt *time.Time `json:"onboarding_completed_at,omitempty"`
+}
+
+// WebhookService delivers events to registered HTTPS endpoints, signing each request
+// and retrying failures with backoff
+type WebhookService struct {
+	subscriptions map[string]*models.WebhookSubscription // subscriptionID -> subscription, with secret
+	deliveries    map[string]*models.WebhookDelivery     // deliveryID -> delivery
+	order         []string                               // delivery IDs, oldest first
+	client        *http.Client
+	redactor      *Redactor
+	retryDelays   []time.Duration
+	allowHTTP     bool
+	background    *Background
+	logger        *slog.Logger
+	mu            sync.Mutex
+}
+
+// NewWebhookService creates a new webhook service. Personal data in event payloads is
+// removed with redactor. Plain http endpoints are refused unless cfg.AllowHTTP is set.
+// Deliveries run on background so shutdown can wait for them.
+func NewWebhookService(redactor *Redactor, cfg config.WebhooksConfig, background *Background, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		subscriptions: make(map[string]*models.WebhookSubscription),
+		deliveries:    make(map[string]*models.WebhookDelivery),
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// A redirect counts as a failed delivery rather than being followed
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		redactor:    redactor,
+		retryDelays: webhookRetryDelays,
+		allowHTTP:   cfg.AllowHTTP,
+		background:  background,
+		logger:      logger,
+	}
+}
+
+// CreateSubscription registers an endpoint. The returned subscription includes the
+// signing secret, which is not shown again.
+func (s *WebhookService) CreateSubscription(createdBy string, req models.CreateWebhookRequest) (*models.WebhookSubscription, error) {
+	endpoint, err := url.Parse(req.URL)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "https" && !(s.allowHTTP && endpoint.Scheme == "http")) {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	var events []string
+	for _, event := range req.Events {
+		if event != webhookAllEvents && !slices.Contains(WebhookEvents, event) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, event)
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	sub := &models.WebhookSubscription{
+		ID:          uuid.New().String(),
+		URL:         endpoint.String(),
+		Events:      events,
+		Description: req.Description,
+		Secret:      "whsec_" + hex.EncodeToString(secret),
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+
+	s.mu.Lock()
+	s.subscriptions[sub.ID] = sub
+	s.mu.Unlock()
+
+	created := *sub
+	created.Events = slices.Clone(sub.Events)
+	return &created, nil
+}
+
+// publicSubscription returns a copy of sub without its secret
+func publicSubscription(sub *models.WebhookSubscription) *models.WebhookSubscription {
+	c := *sub
+	c.Events = slices.Clone(sub.Events)
+	c.Secret = ""
+	return &c
+}
+
+// ListSubscriptions returns all subscriptions, oldest first, without their secrets
+func (s *WebhookService) ListSubscriptions() []*models.WebhookSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*models.WebhookSubscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		result = append(result, publicSubscription(sub))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result
+}
+
+// GetSubscription returns a subscription without its secret
+func (s *WebhookService) GetSubscription(subscriptionID string) (*models.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, exists := s.subscriptions[subscriptionID]
+	if !exists {
+		return nil, ErrWebhookNotFound
+	}
+	return publicSubscription(sub), nil
+}
+
+// DeleteSubscription removes a subscription. Pending retries for it are abandoned.
+func (s *WebhookService) DeleteSubscription(subscriptionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.subscriptions[subscriptionID]; !exists {
+		return ErrWebhookNotFound
+	}
+	delete(s.subscriptions, subscriptionID)
+	return nil
+}
+
+// subscribes reports whether sub wants events of the given type
+func subscribes(sub *models.WebhookSubscription, eventType string) bool {
+	return slices.Contains(sub.Events, webhookAllEvents) || slices.Contains(sub.Events, eventType)
+}
+
+// Publish sends an event to every subscription that wants it. Delivery happens in the
+// background, so Publish never blocks on subscriber endpoints.
+func (s *WebhookService) Publish(eventType string, data interface{}) {
+	event := models.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      s.eventData(data),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("failed to encode webhook event", "event_type", eventType, "error", err)
+		return
+	}
+
+	userID := eventUserID(data)
+
+	s.mu.Lock()
+	var deliveryIDs []string
+	for _, sub := range s.subscriptions {
+		if subscribes(sub, eventType) {
+			delivery := s.addDeliveryLocked(sub.ID, event.ID, eventType, userID, payload, "")
+			deliveryIDs = append(deliveryIDs, delivery.ID)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range deliveryIDs {
+		s.deliver(id)
+	}
+}
+
+// eventData converts models to the data sent to subscribers, removing personal data
+func (s *WebhookService) eventData(data interface{}) interface{} {
+	switch v := data.(type) {
+	case *models.Feedback:
+		return s.redactor.RedactFeedback(v)
+	case *models.User:
+		return webhookUser{
+			ID:                    v.ID,
+			Email:                 s.redactor.Redact(v.Email),
+			Locale:                v.Locale,
+			CreatedAt:             v.CreatedAt,
+			OnboardingCompletedAt: v.OnboardingCompletedAt,
+		}
+	}
+	return data
+}
+
+// eventUserID returns the ID of the user an event is about, if any
+func eventUserID(data interface{}) string {
+	switch v := data.(type) {
+	case *models.Feedback:
+		return v.UserID
+	case *models.User:
+		return v.ID
+	}
+	return ""
+}
+
+// addDeliveryLocked records a pending delivery, dropping the oldest from the log when
+// it is full. Must be called with the lock held.
+func (s *WebhookService) addDeliveryLocked(subscriptionID, eventID, eventType, userID string, payload []byte, redeliveryOf string) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		ID:             uuid.New().String(),
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         models.WebhookDeliveryPending,
+		Attempts:       []*models.WebhookAttempt{},
+		RedeliveryOf:   redeliveryOf,
+		UserID:         userID,
+		CreatedAt:      time.Now(),
+	}
+	s.deliveries[delivery.ID] = delivery
+	s.order = append(s.order, delivery.ID)
+
+	if len(s.order) > maxWebhookDeliveries {
+		delete(s.deliveries, s.order[0])
+		s.order = s.order[1:]
+	}
+	return delivery
+}
+
+// attempt makes one delivery attempt and schedules a retry if it failed
+func (s *WebhookService) attempt(deliveryID string) {
+	s.mu.Lock()
+	delivery, exists := s.deliveries[deliveryID]
+	if !exists || delivery.Status != models.WebhookDeliveryPending {
+		s.mu.Unlock()
+		return
+	}
+	sub, subscribed := s.subscriptions[delivery.SubscriptionID]
+	if !subscribed {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		s.mu.Unlock()
+		return
+	}
+	endpoint, secret, eventType, payload := sub.URL, sub.Secret, delivery.EventType, delivery.Payload
+	s.mu.Unlock()
+
+	attempt, ok := s.send(endpoint, secret, deliveryID, eventType, payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.NextAttemptAt = nil
+	if ok {
+		delivery.Status = models.WebhookDeliverySucceeded
+		return
+	}
+
+	retry := len(delivery.Attempts) - 1
+	if retry >= len(s.retryDelays) {
+		delivery.Status = models.WebhookDeliveryFailed
+		s.logger.Warn("webhook delivery failed", "delivery_id", deliveryID, "endpoint", endpoint, "attempts", len(delivery.Attempts))
+		return
+	}
+	next := time.Now().Add(s.retryDelays[retry])
+	delivery.NextAttemptAt = &next
+	time.AfterFunc(s.retryDelays[retry], func() { s.deliver(deliveryID) })
+}
+
+// deliver attempts a delivery in the background. Nothing is started once shutdown
+// has begun; the delivery stays pending.
+func (s *WebhookService) deliver(deliveryID string) {
+	s.background.Go(func() { s.attempt(deliveryID) })
+}
+
+// send posts a signed payload and reports whether the endpoint accepted it with a 2xx
+func (s *WebhookService) send(endpoint, secret, deliveryID, eventType string, payload []byte) (*models.WebhookAttempt, bool) {
+	attempt := &models.WebhookAttempt{AttemptedAt: time.Now()}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, false
+	}
+	timestamp := strconv.FormatInt(attempt.AttemptedAt.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "onboarding-backend-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set(WebhookSignatureHeader, "t="+timestamp+",v1="+signWebhookPayload(secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	attempt.DurationMs = time.Since(attempt.AttemptedAt).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, false
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseExcerpt))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // let the connection be reused
+	attempt.StatusCode = resp.StatusCode
+	attempt.Response = string(excerpt)
+	return attempt, resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// signWebhookPayload returns the hex HMAC-SHA256 of "<timestamp>.<payload>". Including
+// the timestamp lets receivers reject replayed requests.
+func signWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// copyDelivery returns a snapshot of a delivery that is safe to use after the lock is released
+func copyDelivery(d *models.WebhookDelivery) *models.WebhookDelivery {
+	c := *d
+	c.Attempts = slices.Clone(d.Attempts)
+	return &c
+}
+
+// ListDeliveries returns up to limit of a subscription's logged deliveries, newest first
+func (s *WebhookService) ListDeliveries(subscriptionID string, limit int) ([]*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.subscriptions[subscriptionID]; !exists {
+		return nil, ErrWebhookNotFound
+	}
+
+	result := []*models.WebhookDelivery{}
+	for i := len(s.order) - 1; i >= 0 && len(result) < limit; i-- {
+		if d := s.deliveries[s.order[i]]; d.SubscriptionID == subscriptionID {
+			result = append(result, copyDelivery(d))
+		}
+	}
+	return result, nil
+}
+
+// GetDelivery returns one logged delivery of a subscription
+func (s *WebhookService) GetDelivery(subscriptionID, deliveryID string) (*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, exists := s.deliveries[deliveryID]
+	if !exists || d.SubscriptionID != subscriptionID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return copyDelivery(d), nil
+}
+
+// Redeliver sends a logged delivery's payload again as a new delivery. The event ID is
+// unchanged so receivers can recognise the duplicate.
+func (s *WebhookService) Redeliver(subscriptionID, deliveryID string) (*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	if _, exists := s.subscriptions[subscriptionID]; !exists {
+		s.mu.Unlock()
+		return nil, ErrWebhookNotFound
+	}
+	original, exists := s.deliveries[deliveryID]
+	if !exists || original.SubscriptionID != subscriptionID {
+		s.mu.Unlock()
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	delivery := s.addDeliveryLocked(subscriptionID, original.EventID, original.EventType, original.UserID, original.Payload, original.ID)
+	snapshot := copyDelivery(delivery)
+	s.mu.Unlock()
+
+	s.deliver(delivery.ID)
+	return snapshot, nil
+}
+
+// DeleteByUser removes every logged delivery of an event about a user, including
+// pending retries, and returns how many were removed
+func (s *WebhookService) DeleteByUser(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if s.deliveries[id].UserID == userID {
+			delete(s.deliveries, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	removed := len(s.order) - len(kept)
+	s.order = kept
+	return removed
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/models"
+)
+
+// newTestWebhooks returns a webhook service that allows plain http test servers, with
+// a subscription to every event at url
+func newTestWebhooks(t *testing.T, url string) (*WebhookService, *models.WebhookSubscription, *Background) {
+	t.Helper()
+	background := NewBackground()
+	s := NewWebhookService(newTestRedactor(t), config.WebhooksConfig{AllowHTTP: true}, background, newTestLogger())
+	sub, err := s.CreateSubscription("admin-1", models.CreateWebhookRequest{URL: url, Events: []string{"*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, sub, background
+}
+
+// waitForDelivery waits until a subscription's newest delivery is no longer pending
+func waitForDelivery(t *testing.T, s *WebhookService, subscriptionID string) *models.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := s.ListDeliveries(subscriptionID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) == 1 && deliveries[0].Status != models.WebhookDeliveryPending {
+			return deliveries[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("delivery still pending")
+	return nil
+}
+
+func TestWebhookDeliveriesAreSigned(t *testing.T) {
+	var secret string
+	received := make(chan error, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- func() error {
+			var timestamp, signature string
+			for _, part := range strings.Split(r.Header.Get(WebhookSignatureHeader), ",") {
+				key, value, _ := strings.Cut(part, "=")
+				switch key {
+				case "t":
+					timestamp = value
+				case "v1":
+					signature = value
+				}
+			}
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(timestamp + "." + string(body)))
+			if want := hex.EncodeToString(mac.Sum(nil)); !hmac.Equal([]byte(signature), []byte(want)) {
+				return fmt.Errorf("signature %q over t=%s doesn't match the body", signature, timestamp)
+			}
+			if event := r.Header.Get("X-Webhook-Event"); event != EventFeedbackCreated {
+				return fmt.Errorf("event header %q, want %q", event, EventFeedbackCreated)
+			}
+
+			var event models.WebhookEvent
+			if err := json.Unmarshal(body, &event); err != nil {
+				return err
+			}
+			data := event.Data.(map[string]interface{})
+			if data["id"] != "fb-1" || data["email"] != "[email]" {
+				return fmt.Errorf("event data %v, want feedback fb-1 with the email redacted", data)
+			}
+			return nil
+		}()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	s, sub, _ := newTestWebhooks(t, receiver.URL)
+	secret = sub.Secret
+	s.Publish(EventFeedbackCreated, &models.Feedback{ID: "fb-1", Email: "jane@example.com", Content: "Nice"})
+
+	if err := <-received; err != nil {
+		t.Error(err)
+	}
+	if delivery := waitForDelivery(t, s, sub.ID); delivery.Status != models.WebhookDeliverySucceeded {
+		t.Errorf("delivery %s, want succeeded", delivery.Status)
+	}
+}
+
+func TestWebhookDeliveriesRetryFailures(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	s, sub, _ := newTestWebhooks(t, receiver.URL)
+	s.retryDelays = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	s.Publish(EventUserCreated, &models.User{ID: "user-1", Email: "jane@example.com"})
+
+	delivery := waitForDelivery(t, s, sub.ID)
+	if delivery.Status != models.WebhookDeliverySucceeded || len(delivery.Attempts) != 3 {
+		t.Fatalf("delivery %s after %d attempts, want succeeded after 3", delivery.Status, len(delivery.Attempts))
+	}
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK} {
+		if got := delivery.Attempts[i].StatusCode; got != want {
+			t.Errorf("attempt %d status %d, want %d", i+1, got, want)
+		}
+	}
+	if got := delivery.Attempts[0].Response; got != "try again\n" {
+		t.Errorf("attempt 1 response %q, want the body excerpt", got)
+	}
+	if delivery.NextAttemptAt != nil {
+		t.Errorf("finished delivery still has a next attempt at %v", delivery.NextAttemptAt)
+	}
+}
+
+func TestWebhookDeliveriesFailAfterTheLastRetry(t *testing.T) {
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// Redirects are not followed
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer receiver.Close()
+
+	s, sub, _ := newTestWebhooks(t, receiver.URL)
+	s.retryDelays = []time.Duration{time.Millisecond}
+	s.Publish(EventUserCreated, &models.User{ID: "user-1"})
+
+	delivery := waitForDelivery(t, s, sub.ID)
+	if delivery.Status != models.WebhookDeliveryFailed || len(delivery.Attempts) != 2 {
+		t.Errorf("delivery %s after %d attempts, want failed after 2", delivery.Status, len(delivery.Attempts))
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("receiver got %d requests, want 2", n)
+	}
+}
+
+func TestWebhookDeliveryLogIsCapped(t *testing.T) {
+	s, sub, _ := newTestWebhooks(t, "https://hooks.example.com/feedback")
+
+	s.mu.Lock()
+	var first []string
+	for i := 0; i < maxWebhookDeliveries+5; i++ {
+		delivery := s.addDeliveryLocked(sub.ID, fmt.Sprintf("event-%d", i), EventUserCreated, "user-1", []byte("{}"), "")
+		if i < 5 {
+			first = append(first, delivery.ID)
+		}
+	}
+	s.mu.Unlock()
+
+	deliveries, err := s.ListDeliveries(sub.ID, 2*maxWebhookDeliveries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != maxWebhookDeliveries {
+		t.Fatalf("log holds %d deliveries, want %d", len(deliveries), maxWebhookDeliveries)
+	}
+	if newest, oldest := deliveries[0].EventID, deliveries[len(deliveries)-1].EventID; newest != "event-1004" || oldest != "event-5" {
+		t.Errorf("log runs from %s back to %s, want event-1004 back to event-5", newest, oldest)
+	}
+	for _, id := range first {
+		if _, err := s.GetDelivery(sub.ID, id); err != ErrWebhookDeliveryNotFound {
+			t.Errorf("oldest delivery %s: err = %v, want it dropped", id, err)
+		}
+	}
+}
+
+func TestWebhookPublishHandsDeliveryToTheBackground(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	s, sub, background := newTestWebhooks(t, receiver.URL)
+
+	// Publish returns while the receiver is still holding the request
+	published := make(chan struct{})
+	go func() {
+		s.Publish(EventUserCreated, &models.User{ID: "user-1"})
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on the receiver")
+	}
+
+	// Shutdown waits for the delivery in flight
+	waited := make(chan error, 1)
+	go func() {
+		_, err := background.Wait(context.Background())
+		waited <- err
+	}()
+	select {
+	case err := <-waited:
+		t.Fatalf("Wait returned %v before the delivery finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-waited; err != nil {
+		t.Fatal(err)
+	}
+	if delivery := waitForDelivery(t, s, sub.ID); delivery.Status != models.WebhookDeliverySucceeded {
+		t.Errorf("delivery %s, want succeeded", delivery.Status)
+	}
+
+	// Once shutdown has begun, new deliveries stay pending instead of being sent
+	s.Publish(EventUserCreated, &models.User{ID: "user-2"})
+	deliveries, err := s.ListDeliveries(sub.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveries[0].Status != models.WebhookDeliveryPending || requests.Load() != 1 {
+		t.Errorf("delivery after shutdown is %s with %d requests made, want pending and unsent", deliveries[0].Status, requests.Load())
+	}
+}
@@ -1,10 +1,22 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"onboarding-backend/internal/api"
+	"onboarding-backend/internal/config"
+	"onboarding-backend/internal/logging"
+	"onboarding-backend/internal/models"
 	"onboarding-backend/internal/services"
 
 	"github.com/gin-contrib/cors"
@@ -14,28 +26,111 @@ import (
 
 func main() {
 	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found or error loading it, using environment variables")
+	envErr := godotenv.Load()
+
+	// Defaults, then the config file, environment variables and flags. There is no
+	// logger yet, so problems are printed for whoever is starting the server.
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	// Remove personal data from everything that leaves the system: integrations and logs
+	redactor, err := services.NewRedactor(cfg.Redaction.Kinds...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\nredaction.kinds: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Structured JSON logs; the standard log package and Gin's debug output go through it too
+	logger := logging.New(os.Stdout, cfg.Logging, redactor)
+	slog.SetDefault(logger)
+	fatal := func(msg string, err error) {
+		logger.Error(msg, "error", err)
+		os.Exit(1)
+	}
+	if envErr != nil {
+		logger.Info("no .env file loaded, using environment variables")
+	}
+
+	if cfg.Server.Environment != config.EnvDevelopment {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	gin.DebugPrintFunc = func(format string, values ...interface{}) {
+		logger.Debug(strings.TrimSpace(strings.TrimPrefix(fmt.Sprintf(format, values...), "[WARNING] ")))
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		logger.Debug("route registered", "method", method, "path", path, "handler", handler)
 	}
 
+	// Background workers stop when the root context ends on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Fire-and-forget publishing to Slack, trackers and webhooks, drained on shutdown
+	background := services.NewBackground()
+
 	// Initialize services
-	emailService := services.NewEmailService()
-	authService := services.NewAuthService(emailService)
-	feedbackService := services.NewFeedbackService()
-	slackService := services.NewMockSlackService()
+	emailService := services.NewEmailService(cfg.Email, logger)
+	webhookService := services.NewWebhookService(redactor, cfg.Webhooks, background, logger)
+	userService := services.NewUserService(emailService, webhookService, cfg.Roles, cfg.Accounts, cfg.Server.BaseURL, logger)
+	authService := services.NewAuthService(emailService, userService, cfg.Auth, cfg.Server.BaseURL, logger)
+	feedbackService := services.NewFeedbackService(userService, emailService, webhookService, cfg.Feedback, logger, services.DefaultModerationChecks(cfg.Feedback)...)
+	slackRouting, err := services.SlackRoutingFromConfig(cfg.Slack)
+	if err != nil {
+		fatal("invalid slack routing", err)
+	}
+	slackService := services.NewMockSlackService(redactor, slackRouting, feedbackService.GetFeedback, logger)
+	ticketService, err := services.NewTicketServiceFromConfig(cfg.Tickets, feedbackService, redactor, logger)
+	if err != nil {
+		fatal("invalid ticket configuration", err)
+	}
+	slackInteractionService := services.NewSlackInteractionService(cfg.Slack.SigningSecret, feedbackService, ticketService)
+	idempotencyService := services.NewIdempotencyService(cfg.Idempotency)
+	analyticsService := services.NewAnalyticsService(feedbackService, userService, cfg.Analytics)
+	passkeyService, err := services.NewPasskeyService(authService, cfg.Passkeys)
+	if err != nil {
+		fatal("failed to configure passkeys", err)
+	}
+	blobStore, err := services.NewLocalBlobStore(cfg.Attachments.Dir)
+	if err != nil {
+		fatal("failed to create attachment storage", err)
+	}
+	attachmentService := services.NewAttachmentService(blobStore, feedbackService, cfg.Attachments, cfg.Server.BaseURL, logger)
+	accountService := services.NewAccountService(userService, authService, passkeyService, feedbackService, attachmentService, webhookService, slackService, emailService, idempotencyService, cfg.Accounts, logger)
+
+	// Permanently delete accounts whose deletion grace period has ended
+	accountService.StartPurger(ctx, time.Hour)
+
+	// Post low-priority feedback to Slack as one daily summary
+	slackService.StartDigest(ctx)
 
-	// Create Gin router
-	router := gin.Default()
+	// Create Gin router with request IDs, access logs and panic recovery
+	router := gin.New()
+	router.Use(api.RequestID(), api.RequestLogger(logger), api.Recovery(logger))
 
 	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"} // In production, specify exact origins
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-	router.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = cfg.Server.CORSOrigins
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key", api.RequestIDHeader}
+	corsConfig.ExposeHeaders = []string{api.RequestIDHeader}
+	router.Use(cors.New(corsConfig))
 
 	// Initialize API handlers
 	authHandler := api.NewAuthHandler(authService)
-	feedbackHandler := api.NewFeedbackHandler(feedbackService, slackService, authService)
+	feedbackHandler := api.NewFeedbackHandler(feedbackService, attachmentService, slackService, ticketService, authService, background, logger)
+	passkeyHandler := api.NewPasskeyHandler(passkeyService)
+	userHandler := api.NewUserHandler(userService, accountService)
+	adminHandler := api.NewAdminHandler(feedbackService, analyticsService, attachmentService, slackService, ticketService, userService, authService, redactor, background, logger)
+	webhookHandler := api.NewWebhookHandler(webhookService)
+	slackHandler := api.NewSlackHandler(slackInteractionService, background, logger)
+	attachmentHandler := api.NewAttachmentHandler(attachmentService, feedbackService, slackService, background, logger)
+
+	feedbackBodyLimit := api.MaxBodySize(api.MaxFeedbackBodySize)
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -44,6 +139,7 @@ func main() {
 
 	// Web routes (for email links)
 	router.GET("/auth/verify", authHandler.VerifyMagicLinkWeb)
+	router.GET("/auth/confirm-email", userHandler.ConfirmEmailChangeWeb)
 
 	// Auth routes
 	authRoutes := router.Group("/api/auth")
@@ -53,22 +149,127 @@ func main() {
 		authRoutes.POST("/refresh", authHandler.RefreshToken)
 	}
 
+	// Passkey routes (registration requires an existing session)
+	passkeyRoutes := router.Group("/api/auth/passkey")
+	{
+		passkeyRoutes.POST("/login/begin", passkeyHandler.BeginLogin)
+		passkeyRoutes.POST("/login/finish", passkeyHandler.FinishLogin)
+		passkeyRoutes.POST("/register/begin", authHandler.AuthMiddleware(), passkeyHandler.BeginRegistration)
+		passkeyRoutes.POST("/register/finish", authHandler.AuthMiddleware(), passkeyHandler.FinishRegistration)
+		passkeyRoutes.GET("/credentials", authHandler.AuthMiddleware(), passkeyHandler.ListCredentials)
+	}
+
+	// Account routes (protected)
+	meRoutes := router.Group("/api/me")
+	meRoutes.Use(authHandler.AuthMiddleware())
+	{
+		meRoutes.GET("", userHandler.GetMe)
+		meRoutes.PATCH("", userHandler.UpdateMe)
+		meRoutes.DELETE("", userHandler.DeleteMe)
+		meRoutes.GET("/export", userHandler.ExportMe)
+		meRoutes.POST("/email", userHandler.RequestEmailChange)
+		meRoutes.POST("/onboarding/complete", userHandler.CompleteOnboarding)
+	}
+
 	// Feedback routes (protected)
 	feedbackRoutes := router.Group("/api/feedback")
 	feedbackRoutes.Use(authHandler.AuthMiddleware())
 	{
-		feedbackRoutes.POST("/submit", feedbackHandler.SubmitFeedback)
+		feedbackRoutes.POST("/submit", feedbackBodyLimit, api.Idempotency(idempotencyService), feedbackHandler.SubmitFeedback)
 		feedbackRoutes.GET("/list", feedbackHandler.ListFeedback)
+		feedbackRoutes.GET("/:id", feedbackHandler.GetFeedback)
+		feedbackRoutes.PATCH("/:id", feedbackBodyLimit, feedbackHandler.UpdateFeedback)
+		feedbackRoutes.DELETE("/:id", feedbackHandler.DeleteFeedback)
+		feedbackRoutes.POST("/:id/replies", feedbackBodyLimit, feedbackHandler.ReplyToFeedback)
+		feedbackRoutes.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+	}
+
+	// Slack interactions (authorized by Slack's request signature)
+	router.POST("/api/slack/interactions", slackHandler.HandleInteraction)
+
+	// Attachment downloads (authorized by the link's signature)
+	router.GET("/api/attachments/:id", attachmentHandler.DownloadAttachment)
+
+	// Admin routes (support staff can read, only admins can change accounts)
+	adminRoutes := router.Group("/api/admin")
+	adminRoutes.Use(authHandler.AuthMiddleware(), authHandler.RequireRole(models.RoleSupport, models.RoleAdmin))
+	{
+		adminRoutes.GET("/feedback", adminHandler.ListAllFeedback)
+		adminRoutes.GET("/feedback/search", adminHandler.SearchFeedback)
+		adminRoutes.GET("/feedback/analytics", adminHandler.FeedbackAnalytics)
+		adminRoutes.GET("/feedback/export", adminHandler.ExportFeedback)
+		adminRoutes.GET("/feedback/:id", adminHandler.GetFeedback)
+		adminRoutes.PATCH("/feedback/:id/status", adminHandler.UpdateFeedbackStatus)
+		adminRoutes.POST("/feedback/:id/moderation", adminHandler.ModerateFeedback)
+		adminRoutes.POST("/feedback/:id/ticket", adminHandler.CreateTicket)
+		adminRoutes.POST("/feedback/:id/replies", feedbackBodyLimit, adminHandler.ReplyToFeedback)
+		adminRoutes.GET("/users", adminHandler.LookupUser)
+		adminRoutes.GET("/users/:id", adminHandler.GetUser)
+		adminRoutes.PATCH("/users/:id/role", authHandler.RequireRole(models.RoleAdmin), adminHandler.UpdateUserRole)
+		adminRoutes.DELETE("/users/:id/sessions", authHandler.RequireRole(models.RoleAdmin), adminHandler.RevokeUserSessions)
+		adminRoutes.DELETE("/users/:id/sessions/:sessionId", authHandler.RequireRole(models.RoleAdmin), adminHandler.RevokeUserSession)
+	}
+
+	// Webhook routes (admins only, since subscriptions receive user data)
+	webhookRoutes := router.Group("/api/admin/webhooks")
+	webhookRoutes.Use(authHandler.AuthMiddleware(), authHandler.RequireRole(models.RoleAdmin))
+	{
+		webhookRoutes.GET("", webhookHandler.ListWebhooks)
+		webhookRoutes.POST("", webhookHandler.CreateWebhook)
+		webhookRoutes.GET("/:id", webhookHandler.GetWebhook)
+		webhookRoutes.DELETE("/:id", webhookHandler.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+		webhookRoutes.GET("/:id/deliveries/:deliveryId", webhookHandler.GetDelivery)
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
 	}
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("server starting", "port", cfg.Server.Port, "environment", cfg.Server.Environment)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal("failed to start server", err)
+	case <-ctx.Done():
 	}
+	// A second signal kills the process without waiting for the drain
+	stop()
+
+	// Drain: stop accepting connections and let in-flight requests finish, then wait for
+	// the background work they started, all within one deadline
+	logger.Info("shutting down, draining in-flight work", "timeout", cfg.Server.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("requests still running at the shutdown deadline", "error", err)
+	}
+	if running, err := background.Wait(shutdownCtx); err != nil {
+		logger.Warn("abandoned background tasks at the shutdown deadline", "count", running)
+	}
+	// Pending digest entries live in memory, so they are posted early or lost. Flushing
+	// runs after the publishers have added theirs and gives up at the deadline.
+	if cfg.Slack.FlushDigestOnShutdown {
+		if sent, err := slackService.SendDigest(shutdownCtx); err != nil {
+			logger.Error("failed to send slack digest", "error", err)
+		} else if sent > 0 {
+			logger.Info("sent pending feedback in an early slack digest", "count", sent)
+		}
+	}
+	if pending := slackService.PendingDigest(); pending > 0 {
+		logger.Warn("dropped feedback waiting for the slack digest", "count", pending)
 	}
+	logger.Info("server stopped")
 }
@@ -26,6 +26,9 @@ export const FeedbackSheet = forwardRef<BottomSheet, FeedbackSheetProps>(
     
     // Use a ref to track if we're currently submitting to prevent race conditions
     const isSubmittingRef = useRef(false);
+
+    // Reused when retrying the same feedback so a retry never creates a duplicate
+    const idempotencyKeyRef = useRef<string | null>(null);
     
     const snapPoints = useMemo(() => ['70%'], []);
 
@@ -48,9 +51,14 @@ export const FeedbackSheet = forwardRef<BottomSheet, FeedbackSheetProps>(
         setError('');
 
         // Submit feedback to backend
+        if (!idempotencyKeyRef.current) {
+          idempotencyKeyRef.current = apiService.createIdempotencyKey();
+        }
         const response = await apiService.submitFeedback(
           feedback.trim(),
-          Platform.OS
+          Platform.OS,
+          {},
+          idempotencyKeyRef.current
         );
 
         if (response.success) {
@@ -64,6 +72,7 @@ export const FeedbackSheet = forwardRef<BottomSheet, FeedbackSheetProps>(
 
           // Clear the feedback
           setFeedback('');
+          idempotencyKeyRef.current = null;
 
           // Close the sheet after a short delay
           setTimeout(() => {
@@ -74,8 +83,10 @@ export const FeedbackSheet = forwardRef<BottomSheet, FeedbackSheetProps>(
         }
       } catch (error: any) {
         console.error('Error submitting feedback:', error);
-        const errorMessage = 
-          error.response?.data?.error || 
+        const fieldError = error.response?.data?.fields?.content;
+        const errorMessage =
+          (fieldError && `Feedback ${fieldError}`) ||
+          error.response?.data?.error ||
           'Failed to submit feedback. Please try again.';
         setError(errorMessage);
         
@@ -151,6 +162,7 @@ export const FeedbackSheet = forwardRef<BottomSheet, FeedbackSheetProps>(
               value={feedback}
               onChangeText={(text) => {
                 setFeedback(text);
+                idempotencyKeyRef.current = null;
                 if (error) setError('');
               }}
               multiline
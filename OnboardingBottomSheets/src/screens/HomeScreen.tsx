@@ -11,6 +11,7 @@ import {
 import { useAppSelector, useAppDispatch } from '../redux/hooks';
 import { setOnboardingCompleted } from '../redux/slices/authSlice';
 import { RootState } from '../redux/store';
+import apiService from '../services/apiService';
 
 export const HomeScreen: React.FC = () => {
   const dispatch = useAppDispatch();
@@ -95,16 +96,24 @@ export const HomeScreen: React.FC = () => {
     }
   }, [userId, isNewUser, hasCompletedOnboarding, hasSeenWelcomeSheet, isInitialized]);
 
+  // Tell the backend so the welcome flow isn't shown again on the next login
+  const markOnboardingCompleted = () => {
+    dispatch(setOnboardingCompleted());
+    apiService.completeOnboarding().catch((error) => {
+      console.error('❌ Failed to record onboarding completion:', error);
+    });
+  };
+
   const handleWelcomeClose = () => {
     console.log('👋 Closing welcome sheet');
     welcomeSheetRef.current?.close();
-    dispatch(setOnboardingCompleted());
+    markOnboardingCompleted();
   };
 
   const handleShowFeedback = () => {
     console.log('💬 Opening feedback sheet');
     welcomeSheetRef.current?.close();
-    dispatch(setOnboardingCompleted());
+    markOnboardingCompleted();
     
     setTimeout(() => {
       feedbackSheetRef.current?.snapToIndex(0);
@@ -114,7 +123,7 @@ export const HomeScreen: React.FC = () => {
   const handleShowReview = () => {
     console.log('⭐ Opening review sheet');
     welcomeSheetRef.current?.close();
-    dispatch(setOnboardingCompleted());
+    markOnboardingCompleted();
     
     setTimeout(() => {
       reviewSheetRef.current?.snapToIndex(0);
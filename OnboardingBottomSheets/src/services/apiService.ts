@@ -41,6 +41,13 @@ interface MagicLinkResponse {
   token?: string;
 }
 
+export interface FeedbackDetails {
+  rating?: number; // 1-5 stars
+  category?: 'bug' | 'idea' | 'praise';
+  screen?: string;
+  tags?: string[];
+}
+
 interface FeedbackResponse {
   success: boolean;
   message: string;
@@ -187,16 +194,40 @@ class ApiService {
     return response.data;
   }
 
+  // Account Endpoints
+
+  async completeOnboarding(): Promise<void> {
+    await this.api.post('/api/me/onboarding/complete');
+  }
+
   // Feedback Endpoints
 
-  async submitFeedback(content: string, platform: string): Promise<FeedbackResponse> {
-    const response = await this.api.post<FeedbackResponse>('/api/feedback/submit', {
-      content,
-      platform,
-    });
+  /**
+   * Submit feedback. Pass the same idempotencyKey when retrying a submission so the
+   * server returns the original response instead of storing a duplicate.
+   */
+  async submitFeedback(
+    content: string,
+    platform: string,
+    details: FeedbackDetails = {},
+    idempotencyKey?: string
+  ): Promise<FeedbackResponse> {
+    const response = await this.api.post<FeedbackResponse>(
+      '/api/feedback/submit',
+      {
+        content,
+        platform,
+        ...details,
+      },
+      idempotencyKey ? { headers: { 'Idempotency-Key': idempotencyKey } } : undefined
+    );
     return response.data;
   }
 
+  createIdempotencyKey(): string {
+    return `${Date.now().toString(36)}-${Math.random().toString(36).slice(2)}${Math.random().toString(36).slice(2)}`;
+  }
+
   async getFeedbackList(): Promise<any> {
     const response = await this.api.get('/api/feedback/list');
     return response.data;